@@ -0,0 +1,46 @@
+package tsutsu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// TokenSource returns the bearer token for a request. It is called for every
+// request, so implementations should cache the token until it expires.
+type TokenSource func(ctx context.Context) (string, error)
+
+type authorizer func(req *http.Request) error
+
+func WithBasicAuth(username, password string) Option {
+	return func(t *Tsutsu) error {
+		t.authorize = func(req *http.Request) error {
+			req.SetBasicAuth(username, password)
+			return nil
+		}
+		return nil
+	}
+}
+
+func WithBearerToken(token string) Option {
+	return WithTokenSource(func(ctx context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+func WithTokenSource(source TokenSource) Option {
+	return func(t *Tsutsu) error {
+		if source == nil {
+			return errors.New("tsutsu: token source must not be nil")
+		}
+		t.authorize = func(req *http.Request) error {
+			token, err := source(req.Context())
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+		return nil
+	}
+}
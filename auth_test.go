@@ -0,0 +1,60 @@
+package tsutsu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCredentials(t1 *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth != "Basic dXNlcjpwYXNz" && auth != "Bearer token-1" && auth != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	var issued int
+	refresh := func(ctx context.Context) (string, error) {
+		issued++
+		if issued > 2 {
+			return "", errors.New("token expired")
+		}
+		if issued == 1 {
+			return "token-1", nil
+		}
+		return "token-2", nil
+	}
+
+	tests := []struct {
+		name    string
+		opt     Option
+		calls   int
+		wantErr bool
+	}{
+		{name: "basic auth", opt: WithBasicAuth("user", "pass"), calls: 1, wantErr: false},
+		{name: "bearer token", opt: WithBearerToken("token-1"), calls: 1, wantErr: false},
+		{name: "wrong bearer token", opt: WithBearerToken("wrong"), calls: 1, wantErr: true},
+		{name: "token source", opt: WithTokenSource(refresh), calls: 2, wantErr: false},
+		{name: "token source error", opt: WithTokenSource(refresh), calls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t, err := New(server.URL, tt.opt)
+			if err != nil {
+				t1.Fatal(err)
+			}
+			for i := 0; i < tt.calls; i++ {
+				_, err = t.Queues()
+			}
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("Queues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,50 +5,146 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/fireworq/fireworq/model"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
+	"time"
 )
 
 type Tsutsu struct {
-	baseURL string
-	client  *http.Client
+	baseURL      *url.URL
+	err          error
+	client       *http.Client
+	timeout      time.Duration
+	userAgent    string
+	header       http.Header
+	retryPolicy  RetryPolicy
+	logger       Logger
+	requestHooks []RequestHook
+	middlewares  []Middleware
+	authorize    authorizer
+	metrics      MetricsRecorder
+
+	versionMu sync.Mutex
+	version   *ServerVersion
+}
+
+func newTsutsu(baseURL string) *Tsutsu {
+	t := &Tsutsu{
+		client:      http.DefaultClient,
+		header:      http.Header{},
+		retryPolicy: NoRetry,
+		metrics:     noopMetrics{},
+	}
+	t.baseURL, t.err = parseBaseURL(baseURL)
+	return t
+}
+
+func New(baseURL string, opts ...Option) (*Tsutsu, error) {
+	t := newTsutsu(baseURL)
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
 }
 
+// NewTsutsu and NewTsutsuWithClient cannot report an invalid base URL, so
+// the error is returned from every call instead.
 func NewTsutsu(baseURL string) *Tsutsu {
-	return &Tsutsu{baseURL: baseURL, client: http.DefaultClient}
+	return newTsutsu(baseURL)
 }
 
 func NewTsutsuWithClient(baseURL string, client *http.Client) *Tsutsu {
-	return &Tsutsu{
-		baseURL: baseURL,
-		client:  client,
+	t := newTsutsu(baseURL)
+	if client != nil {
+		t.client = client
 	}
+	return t
+}
+
+func (t *Tsutsu) BaseURL() string {
+	if t.baseURL == nil {
+		return ""
+	}
+	return t.baseURL.String()
+}
+
+func (t *Tsutsu) request(ctx context.Context, method, uri string, body io.Reader) (*httpBodyDecoder, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok && t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	for key, values := range t.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.authorize != nil {
+		if err := t.authorize(req); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	for _, hook := range t.requestHooks {
+		hook(req)
+	}
+
+	decoder, err := t.do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	decoder.cancel = cancel
+	return decoder, nil
 }
 
 func (t *Tsutsu) do(req *http.Request) (*httpBodyDecoder, error) {
-	res, err := t.client.Do(req)
+	start := time.Now()
+	res, err := t.roundTrip(req)
 	if err != nil {
+		t.metrics.ObserveRequest(Operation(req.Context()), 0, time.Since(start), err)
 		return nil, err
 	}
 
 	if res.StatusCode != http.StatusOK {
 		defer res.Body.Close()
-		return nil, errors.New(fmt.Sprintf("status_code: %d", res.StatusCode))
+		err := newAPIError(req, res)
+		t.metrics.ObserveRequest(Operation(req.Context()), res.StatusCode, time.Since(start), err)
+		return nil, err
 	}
 
+	t.metrics.ObserveRequest(Operation(req.Context()), res.StatusCode, time.Since(start), nil)
 	return newHttpBodyDecoder(res.Body), nil
 }
 
 func (t *Tsutsu) getWithContext(ctx context.Context, uri string) (*httpBodyDecoder, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
-	if err != nil {
-		return nil, err
-	}
-	return t.do(req)
+	return t.request(ctx, http.MethodGet, uri, nil)
 }
 
 func (t *Tsutsu) get(uri string) (*httpBodyDecoder, error) {
@@ -56,25 +152,23 @@ func (t *Tsutsu) get(uri string) (*httpBodyDecoder, error) {
 }
 
 func (t *Tsutsu) putWithContext(ctx context.Context, uri string, r io.Reader) (*httpBodyDecoder, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, r)
-	if err != nil {
-		return nil, err
-	}
-
-	return t.do(req)
+	return t.request(ctx, http.MethodPut, uri, r)
 }
 
 func (t *Tsutsu) put(uri string, r io.Reader) (*httpBodyDecoder, error) {
 	return t.putWithContext(context.Background(), uri, r)
 }
 
-func (t *Tsutsu) httpDeleteWithContext(ctx context.Context, uri string) (*httpBodyDecoder, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uri, nil)
-	if err != nil {
-		return nil, err
-	}
+func (t *Tsutsu) postWithContext(ctx context.Context, uri string, r io.Reader) (*httpBodyDecoder, error) {
+	return t.request(ctx, http.MethodPost, uri, r)
+}
 
-	return t.do(req)
+func (t *Tsutsu) post(uri string, r io.Reader) (*httpBodyDecoder, error) {
+	return t.postWithContext(context.Background(), uri, r)
+}
+
+func (t *Tsutsu) httpDeleteWithContext(ctx context.Context, uri string) (*httpBodyDecoder, error) {
+	return t.request(ctx, http.MethodDelete, uri, nil)
 }
 
 func (t *Tsutsu) httpDelete(uri string) (*httpBodyDecoder, error) {
@@ -86,7 +180,9 @@ func (t *Tsutsu) Queues() ([]model.Queue, error) {
 }
 
 func (t *Tsutsu) QueuesWithContext(ctx context.Context) ([]model.Queue, error) {
-	decoder, err := t.getWithContext(ctx, t.baseURL+"/queues")
+	ctx = withOperation(ctx, "Queues")
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("queues").String())
 	if err != nil {
 		return nil, err
 	}
@@ -101,12 +197,42 @@ func (t *Tsutsu) QueuesWithContext(ctx context.Context) ([]model.Queue, error) {
 	return queues, nil
 }
 
+// QueueSpecs lists the queues with their throttling settings, which Queues
+// leaves out.
+func (t *Tsutsu) QueueSpecs() ([]QueueSpec, error) {
+	return t.QueueSpecsWithContext(context.Background())
+}
+
+func (t *Tsutsu) QueueSpecsWithContext(ctx context.Context) ([]QueueSpec, error) {
+	ctx = withOperation(ctx, "QueueSpecs")
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("queues").String())
+	if err != nil {
+		return nil, err
+	}
+
+	defer decoder.Close()
+
+	var queues []QueueSpec
+	if err := decoder.Decode(&queues); err != nil {
+		return nil, err
+	}
+
+	return queues, nil
+}
+
 func (t *Tsutsu) Queue(name string) (model.Queue, error) {
 	return t.QueueWithContext(context.Background(), name)
 }
 
 func (t *Tsutsu) QueueWithContext(ctx context.Context, name string) (model.Queue, error) {
-	decoder, err := t.getWithContext(ctx, fmt.Sprintf("%s/queue/%s", t.baseURL, name))
+	ctx = withOperation(ctx, "Queue")
+
+	if err := validateQueueName(name); err != nil {
+		return model.Queue{}, err
+	}
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("queue", name).String())
 	if err != nil {
 		return model.Queue{}, err
 	}
@@ -126,7 +252,13 @@ func (t *Tsutsu) Stats(queueName string) (QueueStats, error) {
 }
 
 func (t *Tsutsu) StatsWithContext(ctx context.Context, queueName string) (QueueStats, error) {
-	uri := fmt.Sprintf("%s/queue/%s/stats", t.baseURL, queueName)
+	ctx = withOperation(ctx, "Stats")
+
+	if err := validateQueueName(queueName); err != nil {
+		return QueueStats{}, err
+	}
+
+	uri := t.endpoint("queue", queueName, "stats").String()
 	decoder, err := t.getWithContext(ctx, uri)
 	if err != nil {
 		return QueueStats{}, err
@@ -142,12 +274,95 @@ func (t *Tsutsu) StatsWithContext(ctx context.Context, queueName string) (QueueS
 	return stats, nil
 }
 
+func (t *Tsutsu) AllStats() (map[string]QueueStats, error) {
+	return t.AllStatsWithContext(context.Background())
+}
+
+// AllStatsWithContext falls back to one StatsWithContext call per queue when
+// the server has no /queues/stats endpoint.
+func (t *Tsutsu) AllStatsWithContext(ctx context.Context) (map[string]QueueStats, error) {
+	ctx = withOperation(ctx, "AllStats")
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("queues", "stats").String())
+	if errors.Is(err, ErrNotFound) {
+		return t.fanOutStats(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer decoder.Close()
+
+	var stats map[string]QueueStats
+	if err := decoder.Decode(&stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+const maxConcurrentStats = 8
+
+func (t *Tsutsu) fanOutStats(ctx context.Context) (map[string]QueueStats, error) {
+	queues, err := t.QueuesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		stats    = make(map[string]QueueStats, len(queues))
+		sem      = make(chan struct{}, maxConcurrentStats)
+	)
+	for _, queue := range queues {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			s, err := t.StatsWithContext(ctx, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrNotFound):
+				// the queue was deleted after listing
+			case err != nil:
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			default:
+				stats[name] = s
+			}
+		}(queue.Name)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return stats, nil
+}
+
 func (t *Tsutsu) Node(queueName string) (NodeInfo, error) {
 	return t.NodeWithContext(context.Background(), queueName)
 }
 
 func (t *Tsutsu) NodeWithContext(ctx context.Context, queueName string) (NodeInfo, error) {
-	uri := fmt.Sprintf("%s/queue/%s/node", t.baseURL, queueName)
+	ctx = withOperation(ctx, "Node")
+
+	if err := validateQueueName(queueName); err != nil {
+		return NodeInfo{}, err
+	}
+
+	uri := t.endpoint("queue", queueName, "node").String()
 	decoder, err := t.getWithContext(ctx, uri)
 	if err != nil {
 		return NodeInfo{}, err
@@ -167,29 +382,57 @@ func (t *Tsutsu) CreateQueue(name string, pollingInterval, maxWorkers uint) (mod
 }
 
 func (t *Tsutsu) CreateQueueWithContext(ctx context.Context, name string, pollingInterval, maxWorkers uint) (model.Queue, error) {
-	m := model.Queue{
+	ctx = withOperation(ctx, "CreateQueue")
+
+	spec, err := t.PutQueueWithContext(ctx, QueueSpec{
 		Name:            name,
 		PollingInterval: pollingInterval,
 		MaxWorkers:      maxWorkers,
+	})
+	if err != nil {
+		return model.Queue{}, err
+	}
+
+	return spec.Queue(), nil
+}
+
+func (t *Tsutsu) PutQueue(spec QueueSpec) (QueueSpec, error) {
+	return t.PutQueueWithContext(context.Background(), spec)
+}
+
+// PutQueueWithContext creates the queue or replaces its definition.
+// Throttling fields are rejected with ErrUnsupportedByServer on servers that
+// do not know them, instead of being silently dropped.
+func (t *Tsutsu) PutQueueWithContext(ctx context.Context, spec QueueSpec) (QueueSpec, error) {
+	ctx = withOperation(ctx, "PutQueue")
+
+	if err := validateQueueName(spec.Name); err != nil {
+		return QueueSpec{}, err
 	}
 
-	buf, err := json.Marshal(&m)
+	if spec.throttled() {
+		if err := t.requireFeature(ctx, featureQueueThrottling); err != nil {
+			return QueueSpec{}, err
+		}
+	}
+
+	buf, err := json.Marshal(&spec)
 	if err != nil {
-		return model.Queue{}, err
+		return QueueSpec{}, err
 	}
 
 	r := bytes.NewReader(buf)
-	uri := fmt.Sprintf("%s/queue/%s", t.baseURL, name)
+	uri := t.endpoint("queue", spec.Name).String()
 	decoder, err := t.putWithContext(ctx, uri, r)
 	if err != nil {
-		return model.Queue{}, err
+		return QueueSpec{}, err
 	}
 
 	defer decoder.Close()
 
-	var queue model.Queue
+	var queue QueueSpec
 	if err := decoder.Decode(&queue); err != nil {
-		return model.Queue{}, err
+		return QueueSpec{}, err
 	}
 
 	return queue, nil
@@ -200,7 +443,13 @@ func (t *Tsutsu) DeleteQueue(name string) (model.Queue, error) {
 }
 
 func (t *Tsutsu) DeleteQueueWithContext(ctx context.Context, name string) (model.Queue, error) {
-	uri := fmt.Sprintf("%s/queue/%s", t.baseURL, name)
+	ctx = withOperation(ctx, "DeleteQueue")
+
+	if err := validateQueueName(name); err != nil {
+		return model.Queue{}, err
+	}
+
+	uri := t.endpoint("queue", name).String()
 	decoder, err := t.httpDeleteWithContext(ctx, uri)
 	if err != nil {
 		return model.Queue{}, err
@@ -220,7 +469,9 @@ func (t *Tsutsu) Routings() ([]model.Routing, error) {
 }
 
 func (t *Tsutsu) RoutingsWithContext(ctx context.Context) ([]model.Routing, error) {
-	decoder, err := t.getWithContext(ctx, t.baseURL+"/routings")
+	ctx = withOperation(ctx, "Routings")
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("routings").String())
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +492,13 @@ func (t *Tsutsu) Routing(jobCategory string) (model.Routing, error) {
 }
 
 func (t *Tsutsu) RoutingWithContext(ctx context.Context, jobCategory string) (model.Routing, error) {
-	decoder, err := t.getWithContext(ctx, fmt.Sprintf("%s/routing/%s", t.baseURL, jobCategory))
+	ctx = withOperation(ctx, "Routing")
+
+	if err := validateJobCategory(jobCategory); err != nil {
+		return model.Routing{}, err
+	}
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("routing", jobCategory).String())
 	if err != nil {
 		return model.Routing{}, err
 	}
@@ -261,6 +518,16 @@ func (t *Tsutsu) CreateRouting(jobCategory, queueName string) (model.Routing, er
 }
 
 func (t *Tsutsu) CreateRoutingWithContext(ctx context.Context, jobCategory, queueName string) (model.Routing, error) {
+	ctx = withOperation(ctx, "CreateRouting")
+
+	if err := validateJobCategory(jobCategory); err != nil {
+		return model.Routing{}, err
+	}
+
+	if err := validateQueueName(queueName); err != nil {
+		return model.Routing{}, err
+	}
+
 	rt := model.Routing{
 		QueueName:   queueName,
 		JobCategory: jobCategory,
@@ -272,7 +539,7 @@ func (t *Tsutsu) CreateRoutingWithContext(ctx context.Context, jobCategory, queu
 	}
 
 	r := bytes.NewReader(buf)
-	uri := fmt.Sprintf("%s/routing/%s", t.baseURL, jobCategory)
+	uri := t.endpoint("routing", jobCategory).String()
 	decoder, err := t.putWithContext(ctx, uri, r)
 	if err != nil {
 		return model.Routing{}, err
@@ -293,7 +560,13 @@ func (t *Tsutsu) DeleteRouting(jobCategory string) (model.Routing, error) {
 }
 
 func (t *Tsutsu) DeleteRoutingWithContext(ctx context.Context, jobCategory string) (model.Routing, error) {
-	uri := fmt.Sprintf("%s/routing/%s", t.baseURL, jobCategory)
+	ctx = withOperation(ctx, "DeleteRouting")
+
+	if err := validateJobCategory(jobCategory); err != nil {
+		return model.Routing{}, err
+	}
+
+	uri := t.endpoint("routing", jobCategory).String()
 	decoder, err := t.httpDeleteWithContext(ctx, uri)
 	if err != nil {
 		return model.Routing{}, err
@@ -308,10 +581,49 @@ func (t *Tsutsu) DeleteRoutingWithContext(ctx context.Context, jobCategory strin
 	return routing, nil
 }
 
+func (t *Tsutsu) PushJob(category string, job JobRequest) (PushResult, error) {
+	return t.PushJobWithContext(context.Background(), category, job)
+}
+
+func (t *Tsutsu) PushJobWithContext(ctx context.Context, category string, job JobRequest) (PushResult, error) {
+	ctx = withOperation(ctx, "PushJob")
+
+	if err := validateJobCategory(category); err != nil {
+		return PushResult{}, err
+	}
+
+	buf, err := json.Marshal(&job)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	r := bytes.NewReader(buf)
+	uri := t.endpoint("job", category).String()
+	decoder, err := t.postWithContext(ctx, uri, r)
+	if err != nil {
+		return PushResult{}, err
+	}
+
+	defer decoder.Close()
+
+	var result PushResult
+	if err := decoder.Decode(&result); err != nil {
+		return PushResult{}, err
+	}
+
+	return result, nil
+}
+
 func (t *Tsutsu) Job() *JobInspector {
 	return newJobInspector(t)
 }
 
+// Jobs is Job for code depending on Client: its builders return
+// JobInspection instead of *JobInspector.
+func (t *Tsutsu) Jobs() JobInspection {
+	return jobInspection{t.Job()}
+}
+
 type JobInspector struct {
 	client *Tsutsu
 	limit  uint
@@ -348,6 +660,27 @@ func (j *JobInspector) Cursor(cursor string) *JobInspector {
 	return j
 }
 
+// jobInspection adapts the builders of *JobInspector to JobInspection.
+type jobInspection struct {
+	*JobInspector
+}
+
+func (j jobInspection) Limit(limit uint) JobInspection {
+	return jobInspection{j.JobInspector.Limit(limit)}
+}
+
+func (j jobInspection) Asc() JobInspection {
+	return jobInspection{j.JobInspector.Asc()}
+}
+
+func (j jobInspection) Desc() JobInspection {
+	return jobInspection{j.JobInspector.Desc()}
+}
+
+func (j jobInspection) Cursor(cursor string) JobInspection {
+	return jobInspection{j.JobInspector.Cursor(cursor)}
+}
+
 func (j *JobInspector) queryString() string {
 	query := url.Values{}
 	query.Set("limit", strconv.Itoa(int(j.limit)))
@@ -358,6 +691,12 @@ func (j *JobInspector) queryString() string {
 	return query.Encode()
 }
 
+func (j *JobInspector) listURI(queueName, list string) string {
+	u := j.client.endpoint("queue", queueName, list)
+	u.RawQuery = j.queryString()
+	return u.String()
+}
+
 func (j *JobInspector) do(ctx context.Context, uri string) (JobsInfo, error) {
 	decoder, err := j.client.getWithContext(ctx, uri)
 	if err != nil {
@@ -379,7 +718,13 @@ func (j *JobInspector) Grabbed(queueName string) (JobsInfo, error) {
 }
 
 func (j *JobInspector) GrabbedWithContext(ctx context.Context, queueName string) (JobsInfo, error) {
-	uri := fmt.Sprintf("%s/queue/%s/grabbed?%s", j.client.baseURL, queueName, j.queryString())
+	ctx = withOperation(ctx, "GrabbedJobs")
+
+	if err := validateQueueName(queueName); err != nil {
+		return JobsInfo{}, err
+	}
+
+	uri := j.listURI(queueName, "grabbed")
 	return j.do(ctx, uri)
 }
 
@@ -388,12 +733,24 @@ func (j *JobInspector) Waiting(queueName string) (JobsInfo, error) {
 }
 
 func (j *JobInspector) WaitingWithContext(ctx context.Context, queueName string) (JobsInfo, error) {
-	uri := fmt.Sprintf("%s/queue/%s/waiting?%s", j.client.baseURL, queueName, j.queryString())
+	ctx = withOperation(ctx, "WaitingJobs")
+
+	if err := validateQueueName(queueName); err != nil {
+		return JobsInfo{}, err
+	}
+
+	uri := j.listURI(queueName, "waiting")
 	return j.do(ctx, uri)
 }
 
 func (j *JobInspector) DeferredWithContext(ctx context.Context, queueName string) (JobsInfo, error) {
-	uri := fmt.Sprintf("%s/queue/%s/deferred?%s", j.client.baseURL, queueName, j.queryString())
+	ctx = withOperation(ctx, "DeferredJobs")
+
+	if err := validateQueueName(queueName); err != nil {
+		return JobsInfo{}, err
+	}
+
+	uri := j.listURI(queueName, "deferred")
 	return j.do(ctx, uri)
 }
 
@@ -401,8 +758,68 @@ func (j *JobInspector) Deferred(queueName string) (JobsInfo, error) {
 	return j.DeferredWithContext(context.Background(), queueName)
 }
 
+func (j *JobInspector) Get(queueName string, id uint64) (JobInfo, error) {
+	return j.GetWithContext(context.Background(), queueName, id)
+}
+
+func (j *JobInspector) GetWithContext(ctx context.Context, queueName string, id uint64) (JobInfo, error) {
+	ctx = withOperation(ctx, "GetJob")
+
+	if err := validateQueueName(queueName); err != nil {
+		return JobInfo{}, err
+	}
+
+	uri := j.client.endpoint("queue", queueName, "job", strconv.FormatUint(id, 10)).String()
+	decoder, err := j.client.getWithContext(ctx, uri)
+	if err != nil {
+		return JobInfo{}, err
+	}
+
+	defer decoder.Close()
+
+	var job JobInfo
+	if err := decoder.Decode(&job); err != nil {
+		return JobInfo{}, err
+	}
+
+	return job, nil
+}
+
+func (j *JobInspector) Delete(queueName string, id uint64) (JobInfo, error) {
+	return j.DeleteWithContext(context.Background(), queueName, id)
+}
+
+func (j *JobInspector) DeleteWithContext(ctx context.Context, queueName string, id uint64) (JobInfo, error) {
+	ctx = withOperation(ctx, "DeleteJob")
+
+	if err := validateQueueName(queueName); err != nil {
+		return JobInfo{}, err
+	}
+
+	uri := j.client.endpoint("queue", queueName, "job", strconv.FormatUint(id, 10)).String()
+	decoder, err := j.client.httpDeleteWithContext(ctx, uri)
+	if err != nil {
+		return JobInfo{}, err
+	}
+
+	defer decoder.Close()
+
+	var job JobInfo
+	if err := decoder.Decode(&job); err != nil {
+		return JobInfo{}, err
+	}
+
+	return job, nil
+}
+
 func (j *JobInspector) FailedWithContext(ctx context.Context, queueName string) (FailedJobsInfo, error) {
-	uri := fmt.Sprintf("%s/queue/%s/failed?%s", j.client.baseURL, queueName, j.queryString())
+	ctx = withOperation(ctx, "FailedJobs")
+
+	if err := validateQueueName(queueName); err != nil {
+		return FailedJobsInfo{}, err
+	}
+
+	uri := j.listURI(queueName, "failed")
 	decoder, err := j.client.getWithContext(ctx, uri)
 	if err != nil {
 		return FailedJobsInfo{}, err
@@ -421,3 +838,148 @@ func (j *JobInspector) FailedWithContext(ctx context.Context, queueName string)
 func (j *JobInspector) Failed(queueName string) (FailedJobsInfo, error) {
 	return j.FailedWithContext(context.Background(), queueName)
 }
+
+func (j *JobInspector) GetFailed(queueName string, id uint64) (FailedJobInfo, error) {
+	return j.GetFailedWithContext(context.Background(), queueName, id)
+}
+
+func (j *JobInspector) GetFailedWithContext(ctx context.Context, queueName string, id uint64) (FailedJobInfo, error) {
+	ctx = withOperation(ctx, "GetFailedJob")
+
+	if err := validateQueueName(queueName); err != nil {
+		return FailedJobInfo{}, err
+	}
+
+	uri := j.client.endpoint("queue", queueName, "failed", strconv.FormatUint(id, 10)).String()
+	decoder, err := j.client.getWithContext(ctx, uri)
+	if err != nil {
+		return FailedJobInfo{}, err
+	}
+
+	defer decoder.Close()
+
+	var failedJob FailedJobInfo
+	if err := decoder.Decode(&failedJob); err != nil {
+		return FailedJobInfo{}, err
+	}
+
+	return failedJob, nil
+}
+
+func (j *JobInspector) DeleteFailed(queueName string, id uint64) (FailedJobInfo, error) {
+	return j.DeleteFailedWithContext(context.Background(), queueName, id)
+}
+
+func (j *JobInspector) DeleteFailedWithContext(ctx context.Context, queueName string, id uint64) (FailedJobInfo, error) {
+	ctx = withOperation(ctx, "DeleteFailedJob")
+
+	if err := validateQueueName(queueName); err != nil {
+		return FailedJobInfo{}, err
+	}
+
+	uri := j.client.endpoint("queue", queueName, "failed", strconv.FormatUint(id, 10)).String()
+	decoder, err := j.client.httpDeleteWithContext(ctx, uri)
+	if err != nil {
+		return FailedJobInfo{}, err
+	}
+
+	defer decoder.Close()
+
+	var failedJob FailedJobInfo
+	if err := decoder.Decode(&failedJob); err != nil {
+		return FailedJobInfo{}, err
+	}
+
+	return failedJob, nil
+}
+
+func (j *JobInspector) eachJob(ctx context.Context, queueName, list string, fn func(JobInfo) error) error {
+	if err := validateQueueName(queueName); err != nil {
+		return err
+	}
+
+	page := *j
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		uri := page.listURI(queueName, list)
+		jobsInfo, err := page.do(ctx, uri)
+		if err != nil {
+			return err
+		}
+
+		for _, job := range jobsInfo.Jobs {
+			if err := fn(job); err != nil {
+				return err
+			}
+		}
+
+		if jobsInfo.NextCursor == "" || jobsInfo.NextCursor == page.cursor {
+			return nil
+		}
+		page.cursor = jobsInfo.NextCursor
+	}
+}
+
+func (j *JobInspector) GrabbedAll(queueName string, fn func(JobInfo) error) error {
+	return j.GrabbedAllWithContext(context.Background(), queueName, fn)
+}
+
+func (j *JobInspector) GrabbedAllWithContext(ctx context.Context, queueName string, fn func(JobInfo) error) error {
+	ctx = withOperation(ctx, "AllGrabbedJobs")
+
+	return j.eachJob(ctx, queueName, "grabbed", fn)
+}
+
+func (j *JobInspector) WaitingAll(queueName string, fn func(JobInfo) error) error {
+	return j.WaitingAllWithContext(context.Background(), queueName, fn)
+}
+
+func (j *JobInspector) WaitingAllWithContext(ctx context.Context, queueName string, fn func(JobInfo) error) error {
+	ctx = withOperation(ctx, "AllWaitingJobs")
+
+	return j.eachJob(ctx, queueName, "waiting", fn)
+}
+
+func (j *JobInspector) DeferredAll(queueName string, fn func(JobInfo) error) error {
+	return j.DeferredAllWithContext(context.Background(), queueName, fn)
+}
+
+func (j *JobInspector) DeferredAllWithContext(ctx context.Context, queueName string, fn func(JobInfo) error) error {
+	ctx = withOperation(ctx, "AllDeferredJobs")
+
+	return j.eachJob(ctx, queueName, "deferred", fn)
+}
+
+func (j *JobInspector) FailedAll(queueName string, fn func(FailedJobInfo) error) error {
+	return j.FailedAllWithContext(context.Background(), queueName, fn)
+}
+
+func (j *JobInspector) FailedAllWithContext(ctx context.Context, queueName string, fn func(FailedJobInfo) error) error {
+	ctx = withOperation(ctx, "AllFailedJobs")
+
+	page := *j
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		failedJobsInfo, err := page.FailedWithContext(ctx, queueName)
+		if err != nil {
+			return err
+		}
+
+		for _, job := range failedJobsInfo.FailedJobs {
+			if err := fn(job); err != nil {
+				return err
+			}
+		}
+
+		if failedJobsInfo.NextCursor == "" || failedJobsInfo.NextCursor == page.cursor {
+			return nil
+		}
+		page.cursor = failedJobsInfo.NextCursor
+	}
+}
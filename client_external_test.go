@@ -0,0 +1,54 @@
+package tsutsu_test
+
+import (
+	"errors"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"testing"
+)
+
+func TestTsutsu_PutQueue_oldServer(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	s.SetVersion("1.4.0")
+	t := s.Tsutsu()
+
+	spec := tsutsu.QueueSpec{Name: "throttled", PollingInterval: 100, MaxWorkers: 2}
+	if _, err := t.PutQueue(spec); err != nil {
+		t1.Fatalf("PutQueue() without throttling error = %v", err)
+	}
+
+	spec.MaxDispatchesPerSecond = 1.5
+	spec.MaxBurstSize = 3
+	if _, err := t.PutQueue(spec); !errors.Is(err, tsutsu.ErrUnsupportedByServer) {
+		t1.Errorf("PutQueue() error = %v, want ErrUnsupportedByServer", err)
+	}
+}
+
+func TestTsutsu_Jobs(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	for i := 0; i < 3; i++ {
+		if _, err := t.PushJob("mail", tsutsu.JobRequest{URL: "http://localhost/"}); err != nil {
+			t1.Fatal(err)
+		}
+	}
+
+	// Job keeps returning *JobInspector for code that stores it.
+	var inspector *tsutsu.JobInspector = t.Job().Limit(2).Asc()
+	direct, err := inspector.Waiting(tsutsutest.DefaultQueueName)
+	if err != nil {
+		t1.Fatal(err)
+	}
+
+	var client tsutsu.Client = t
+	viaClient, err := client.Jobs().Limit(2).Asc().Waiting(tsutsutest.DefaultQueueName)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if len(viaClient.Jobs) != 2 || len(direct.Jobs) != 2 || viaClient.Jobs[0].ID != direct.Jobs[0].ID || viaClient.NextCursor != direct.NextCursor {
+		t1.Errorf("Jobs() got = %v, want the same page as Job() = %v", viaClient, direct)
+	}
+}
@@ -2,15 +2,18 @@ package tsutsu
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fireworq/fireworq/model"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var FIREWORQ_PORT = os.Getenv("TEST_FIREWORQ_PORT")
@@ -425,3 +428,477 @@ func TestTsutsu_DeleteRouting(t1 *testing.T) {
 		})
 	}
 }
+
+func TestTsutsu_PushJob(t1 *testing.T) {
+	type fields struct {
+		baseURL string
+	}
+	type args struct {
+		category string
+		job      JobRequest
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    PushResult
+		wantErr bool
+	}{
+		{
+			name:   "should be pushed",
+			fields: fields{baseURL: FIREWORQ_URL},
+			args: args{
+				category: "test_push",
+				job: JobRequest{
+					URL:        "http://localhost:1/",
+					Payload:    map[string]string{"key": "value"},
+					RunAfter:   3600,
+					Timeout:    30,
+					MaxRetries: 2,
+					RetryDelay: 10,
+				},
+			},
+			want: PushResult{
+				QueueName:  "default",
+				Category:   "test_push",
+				URL:        "http://localhost:1/",
+				Payload:    json.RawMessage(`{"key":"value"}`),
+				RunAfter:   3600,
+				Timeout:    30,
+				MaxRetries: 2,
+				RetryDelay: 10,
+			},
+			wantErr: false,
+		},
+		{
+			name:   "should be error without url",
+			fields: fields{baseURL: FIREWORQ_URL},
+			args: args{
+				category: "test_push",
+				job:      JobRequest{},
+			},
+			want:    PushResult{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(tt.fields.baseURL)
+			got, err := t.PushJob(tt.args.category, tt.args.job)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("PushJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.ID == 0 {
+				t1.Errorf("PushJob() got ID = 0, want non-zero")
+			}
+			got.ID = 0
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("PushJob() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newInspectionServer(method, path, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method || r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+}
+
+func TestJobInspector_Get(t1 *testing.T) {
+	server := newInspectionServer(http.MethodGet, "/queue/default/job/1",
+		`{"id":1,"category":"test_category","url":"http://localhost/","status":"claimed","created_at":"2020-01-02T03:04:05Z","next_try":"2020-01-02T03:04:05Z","timeout":30,"fail_count":1,"max_retries":3,"retry_delay":10}`)
+	defer server.Close()
+
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	type args struct {
+		queueName string
+		id        uint64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    JobInfo
+		wantErr bool
+	}{
+		{
+			name: "should be return job",
+			args: args{queueName: "default", id: 1},
+			want: JobInfo{
+				ID:         1,
+				Category:   "test_category",
+				URL:        "http://localhost/",
+				Status:     "claimed",
+				CreatedAt:  createdAt,
+				NextTry:    createdAt,
+				Timeout:    30,
+				FailCount:  1,
+				MaxRetries: 3,
+				RetryDelay: 10,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should be error",
+			args:    args{queueName: "default", id: 2},
+			want:    JobInfo{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(server.URL)
+			got, err := t.Job().Get(tt.args.queueName, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("Get() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobInspector_Delete(t1 *testing.T) {
+	server := newInspectionServer(http.MethodDelete, "/queue/default/job/1",
+		`{"id":1,"category":"test_category","url":"http://localhost/","status":"claimed"}`)
+	defer server.Close()
+
+	type args struct {
+		queueName string
+		id        uint64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    JobInfo
+		wantErr bool
+	}{
+		{
+			name: "should be delete",
+			args: args{queueName: "default", id: 1},
+			want: JobInfo{
+				ID:       1,
+				Category: "test_category",
+				URL:      "http://localhost/",
+				Status:   "claimed",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should be error",
+			args:    args{queueName: "not_found", id: 1},
+			want:    JobInfo{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(server.URL)
+			got, err := t.Job().Delete(tt.args.queueName, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("Delete() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobInspector_GetFailed(t1 *testing.T) {
+	server := newInspectionServer(http.MethodGet, "/queue/default/failed/5",
+		`{"id":5,"job_id":1,"category":"test_category","url":"http://localhost/","result":{"status":"permanent-failure","code":500,"message":"boom"},"fail_count":4,"failed_at":"2020-01-02T03:04:05Z","created_at":"2020-01-02T03:04:05Z"}`)
+	defer server.Close()
+
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	type args struct {
+		queueName string
+		id        uint64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    FailedJobInfo
+		wantErr bool
+	}{
+		{
+			name: "should be return failed job",
+			args: args{queueName: "default", id: 5},
+			want: FailedJobInfo{
+				ID:       5,
+				JobID:    1,
+				Category: "test_category",
+				URL:      "http://localhost/",
+				Result: &JobResult{
+					Status:  "permanent-failure",
+					Code:    500,
+					Message: "boom",
+				},
+				FailCount: 4,
+				FailedAt:  at,
+				CreatedAt: at,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should be error",
+			args:    args{queueName: "default", id: 6},
+			want:    FailedJobInfo{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(server.URL)
+			got, err := t.Job().GetFailed(tt.args.queueName, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("GetFailed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("GetFailed() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobInspector_DeleteFailed(t1 *testing.T) {
+	server := newInspectionServer(http.MethodDelete, "/queue/default/failed/5",
+		`{"id":5,"job_id":1,"category":"test_category","url":"http://localhost/","result":{"status":"failure","code":503,"message":""},"fail_count":1}`)
+	defer server.Close()
+
+	type args struct {
+		queueName string
+		id        uint64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    FailedJobInfo
+		wantErr bool
+	}{
+		{
+			name: "should be delete",
+			args: args{queueName: "default", id: 5},
+			want: FailedJobInfo{
+				ID:        5,
+				JobID:     1,
+				Category:  "test_category",
+				URL:       "http://localhost/",
+				Result:    &JobResult{Status: "failure", Code: 503},
+				FailCount: 1,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should be error",
+			args:    args{queueName: "not_found", id: 5},
+			want:    FailedJobInfo{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(server.URL)
+			got, err := t.Job().DeleteFailed(tt.args.queueName, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("DeleteFailed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("DeleteFailed() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobInspector_WaitingAll(t1 *testing.T) {
+	pages := map[string]string{
+		"":   `{"jobs":[{"id":3},{"id":2}],"next_cursor":"c1"}`,
+		"c1": `{"jobs":[{"id":1}],"next_cursor":""}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/queue/default/waiting" || query.Get("limit") != "2" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, pages[query.Get("cursor")])
+	}))
+	defer server.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    []uint64
+		wantErr bool
+	}{
+		{
+			name:    "should follow cursors",
+			ctx:     context.Background(),
+			want:    []uint64{3, 2, 1},
+			wantErr: false,
+		},
+		{
+			name:    "should stop when canceled",
+			ctx:     canceled,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(server.URL)
+			var got []uint64
+			err := t.Job().Limit(2).WaitingAllWithContext(tt.ctx, "default", func(job JobInfo) error {
+				got = append(got, job.ID)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("WaitingAllWithContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("WaitingAllWithContext() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTsutsu_Stats(t1 *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/queue/default/stats" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"total_pushes":3,"total_pops":2,"pushes_per_second":5,"pops_per_second":7,"total_workers":20,"idle_workers":19,"active_nodes":1}`)
+	}))
+	defer server.Close()
+
+	got, err := NewTsutsu(server.URL).Stats("default")
+	if err != nil {
+		t1.Fatal(err)
+	}
+	want := QueueStats{TotalPushes: 3, TotalPops: 2, PushesPerSecond: 5, PopPerSecond: 7, TotalWorkers: 20, IdleWorkers: 19, ActiveNodes: 1}
+	if !reflect.DeepEqual(got, want) {
+		t1.Errorf("Stats() got = %v, want %v", got, want)
+	}
+}
+
+func TestTsutsu_AllStats(t1 *testing.T) {
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queues":
+			io.WriteString(w, `[{"name":"default"},{"name":"deleted"},{"name":"test_queue"}]`)
+		case "/queue/default/stats":
+			io.WriteString(w, `{"total_pushes":3,"total_workers":20,"active_nodes":1}`)
+		case "/queue/test_queue/stats":
+			io.WriteString(w, `{"total_pushes":1,"total_workers":1,"active_nodes":1}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer legacy.Close()
+
+	type fields struct {
+		baseURL string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    map[string]QueueStats
+		wantErr bool
+	}{
+		{
+			name:   "should fan out without /queues/stats",
+			fields: fields{baseURL: legacy.URL},
+			want: map[string]QueueStats{
+				"default":    {TotalPushes: 3, TotalWorkers: 20, ActiveNodes: 1},
+				"test_queue": {TotalPushes: 1, TotalWorkers: 1, ActiveNodes: 1},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(tt.fields.baseURL)
+			got, err := t.AllStats()
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("AllStats() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("AllStats() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t1.Run("should use /queues/stats", func(t1 *testing.T) {
+		got, err := NewTsutsu(FIREWORQ_URL).AllStats()
+		if err != nil {
+			t1.Fatal(err)
+		}
+		if stats, ok := got["default"]; !ok || stats.TotalWorkers != 20 {
+			t1.Errorf("AllStats() got = %v, want default queue with 20 workers", got)
+		}
+	})
+}
+
+func TestTsutsu_PutQueue(t1 *testing.T) {
+	defer NewTsutsu(FIREWORQ_URL).DeleteQueue("test_spec_queue")
+
+	type fields struct {
+		baseURL string
+	}
+	type args struct {
+		spec QueueSpec
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    QueueSpec
+		wantErr bool
+	}{
+		{
+			name:   "should be created",
+			fields: fields{baseURL: FIREWORQ_URL},
+			args: args{spec: QueueSpec{
+				Name:            "test_spec_queue",
+				PollingInterval: 100,
+				MaxWorkers:      2,
+			}},
+			want: QueueSpec{
+				Name:            "test_spec_queue",
+				PollingInterval: 100,
+				MaxWorkers:      2,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t := NewTsutsu(tt.fields.baseURL)
+			got, err := t.PutQueue(tt.args.spec)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("PutQueue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("PutQueue() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
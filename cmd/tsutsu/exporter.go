@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stk132/tsutsu/exporter"
+	"net/http"
+	"time"
+)
+
+func exporterCommand(a *app, args []string) error {
+	fs := a.flagSet("exporter", "")
+	listen := fs.String("listen", ":9649", "address to serve metrics on")
+	path := fs.String("path", "/metrics", "path to serve metrics on")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each scrape of Fireworq")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, exporter.New(client).Timeout(*timeout))
+	fmt.Fprintf(a.stderr, "tsutsu: serving metrics of %s on %s%s\n", client.BaseURL(), *listen, *path)
+	return http.ListenAndServe(*listen, mux)
+}
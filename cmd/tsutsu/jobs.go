@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stk132/tsutsu"
+	"strings"
+	"time"
+)
+
+// record holds the fields of a job by their JSON names.
+type record map[string]interface{}
+
+// jobList is one of the job lists of a queue.
+type jobList struct {
+	name          string
+	key           string
+	fields        []string
+	defaultFields []string
+	page          func(ctx context.Context, inspection tsutsu.JobInspection, queueName string) ([]record, string, error)
+	all           func(inspection tsutsu.JobInspection, queueName string, fn func(record) error) error
+}
+
+var (
+	jobFields        = []string{"id", "category", "url", "payload", "status", "created_at", "next_try", "timeout", "fail_count", "max_retries", "retry_delay"}
+	defaultJobFields = []string{"id", "category", "url", "status", "created_at", "next_try", "fail_count"}
+
+	failedJobFields        = []string{"id", "job_id", "category", "url", "payload", "result", "fail_count", "failed_at", "created_at"}
+	defaultFailedJobFields = []string{"id", "job_id", "category", "url", "result", "fail_count", "failed_at"}
+)
+
+func jobRecord(j tsutsu.JobInfo) record {
+	return record{
+		"id":          j.ID,
+		"category":    j.Category,
+		"url":         j.URL,
+		"payload":     j.Payload,
+		"status":      j.Status,
+		"created_at":  j.CreatedAt,
+		"next_try":    j.NextTry,
+		"timeout":     j.Timeout,
+		"fail_count":  j.FailCount,
+		"max_retries": j.MaxRetries,
+		"retry_delay": j.RetryDelay,
+	}
+}
+
+func failedJobRecord(j tsutsu.FailedJobInfo) record {
+	return record{
+		"id":         j.ID,
+		"job_id":     j.JobID,
+		"category":   j.Category,
+		"url":        j.URL,
+		"payload":    j.Payload,
+		"result":     j.Result,
+		"fail_count": j.FailCount,
+		"failed_at":  j.FailedAt,
+		"created_at": j.CreatedAt,
+	}
+}
+
+func newJobList(name string, page func(tsutsu.JobInspection, context.Context, string) (tsutsu.JobsInfo, error), all func(tsutsu.JobInspection, string, func(tsutsu.JobInfo) error) error) jobList {
+	return jobList{
+		name:          name,
+		key:           "jobs",
+		fields:        jobFields,
+		defaultFields: defaultJobFields,
+		page: func(ctx context.Context, inspection tsutsu.JobInspection, queueName string) ([]record, string, error) {
+			jobs, err := page(inspection, ctx, queueName)
+			if err != nil {
+				return nil, "", err
+			}
+			var records []record
+			for _, j := range jobs.Jobs {
+				records = append(records, jobRecord(j))
+			}
+			return records, jobs.NextCursor, nil
+		},
+		all: func(inspection tsutsu.JobInspection, queueName string, fn func(record) error) error {
+			return all(inspection, queueName, func(j tsutsu.JobInfo) error { return fn(jobRecord(j)) })
+		},
+	}
+}
+
+var (
+	grabbedJobs  = newJobList("grabbed", tsutsu.JobInspection.GrabbedWithContext, tsutsu.JobInspection.GrabbedAll)
+	waitingJobs  = newJobList("waiting", tsutsu.JobInspection.WaitingWithContext, tsutsu.JobInspection.WaitingAll)
+	deferredJobs = newJobList("deferred", tsutsu.JobInspection.DeferredWithContext, tsutsu.JobInspection.DeferredAll)
+	failedJobs   = jobList{
+		name:          "failed",
+		key:           "failed_jobs",
+		fields:        failedJobFields,
+		defaultFields: defaultFailedJobFields,
+		page: func(ctx context.Context, inspection tsutsu.JobInspection, queueName string) ([]record, string, error) {
+			jobs, err := inspection.FailedWithContext(ctx, queueName)
+			if err != nil {
+				return nil, "", err
+			}
+			var records []record
+			for _, j := range jobs.FailedJobs {
+				records = append(records, failedJobRecord(j))
+			}
+			return records, jobs.NextCursor, nil
+		},
+		all: func(inspection tsutsu.JobInspection, queueName string, fn func(record) error) error {
+			return inspection.FailedAll(queueName, func(j tsutsu.FailedJobInfo) error { return fn(failedJobRecord(j)) })
+		},
+	}
+)
+
+func jobsGrabbed(a *app, args []string) error  { return a.listJobs(grabbedJobs, args) }
+func jobsWaiting(a *app, args []string) error  { return a.listJobs(waitingJobs, args) }
+func jobsDeferred(a *app, args []string) error { return a.listJobs(deferredJobs, args) }
+func jobsFailed(a *app, args []string) error   { return a.listJobs(failedJobs, args) }
+
+func (a *app) listJobs(l jobList, args []string) error {
+	fs := a.flagSet("jobs "+l.name, "QUEUE_NAME")
+	limit := fs.Uint("limit", 100, "number of jobs per page")
+	asc := fs.Bool("asc", false, "list the oldest jobs first")
+	desc := fs.Bool("desc", false, "list the newest jobs first")
+	cursor := fs.String("cursor", "", "cursor to start from, as printed after the previous page")
+	all := fs.Bool("all", false, "follow the cursor until every job is listed")
+	fields := fs.String("fields", strings.Join(l.defaultFields, ","), "comma separated fields to show: "+strings.Join(l.fields, ","))
+	pretty := fs.Bool("pretty", false, "decode string payloads holding JSON and indent payloads")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *limit == 0 {
+		return usageError("--limit must be positive")
+	}
+	if *asc && *desc {
+		return usageError("--asc and --desc cannot be used together")
+	}
+	selected, err := selectFields(*fields, l.fields)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	inspection := client.Jobs().Limit(*limit)
+	if *asc {
+		inspection = inspection.Asc()
+	}
+	if *desc {
+		inspection = inspection.Desc()
+	}
+	if *cursor != "" {
+		inspection = inspection.Cursor(*cursor)
+	}
+
+	var records []record
+	var next string
+	if *all {
+		err = l.all(inspection, args[0], func(r record) error {
+			records = append(records, r)
+			return nil
+		})
+	} else {
+		records, next, err = l.page(context.Background(), inspection, args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	if *pretty {
+		for _, r := range records {
+			r["payload"] = prettyPayload(r["payload"].(json.RawMessage))
+		}
+	}
+
+	if a.output == outputTable && *pretty {
+		err = writeRecords(a, selected, records)
+	} else {
+		header := make([]string, len(selected))
+		for i, f := range selected {
+			header[i] = strings.ToUpper(f)
+		}
+		t := newTable(header...)
+		for _, r := range records {
+			row := make([]interface{}, len(selected))
+			for i, f := range selected {
+				row[i] = cell(r[f])
+			}
+			t.add(row...)
+		}
+		selections := []selection{}
+		for _, r := range records {
+			selections = append(selections, selection{fields: selected, values: r})
+		}
+		err = a.print(map[string]interface{}{l.key: selections, "next_cursor": next}, t)
+	}
+	if err != nil {
+		return err
+	}
+
+	if next != "" && a.output == outputTable {
+		fmt.Fprintf(a.stderr, "more jobs: --cursor %s\n", next)
+	}
+	return nil
+}
+
+func selectFields(list string, fields []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, f := range fields {
+		known[f] = true
+	}
+
+	var selected []string
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !known[f] {
+			return nil, usageError(fmt.Sprintf("unknown field %q, available fields are %s", f, strings.Join(fields, ",")))
+		}
+		selected = append(selected, f)
+	}
+	if len(selected) == 0 {
+		return nil, usageError("--fields must not be empty")
+	}
+	return selected, nil
+}
+
+// selection encodes the selected fields of a record in their order.
+type selection struct {
+	fields []string
+	values record
+}
+
+func (s selection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range s.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(s.values[f])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return "-"
+		}
+		return v.Format(time.RFC3339)
+	case json.RawMessage:
+		if len(v) == 0 {
+			return "-"
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, v); err != nil {
+			return string(v)
+		}
+		return buf.String()
+	case *tsutsu.JobResult:
+		if v == nil {
+			return "-"
+		}
+		return strings.TrimSpace(fmt.Sprintf("%s %d %s", v.Status, v.Code, v.Message))
+	case string:
+		if v == "" {
+			return "-"
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// prettyPayload indents a payload, decoding it first when it is a JSON string
+// holding JSON, as payloads pushed as strings are.
+func prettyPayload(payload json.RawMessage) json.RawMessage {
+	var s string
+	if err := json.Unmarshal(payload, &s); err == nil && json.Valid([]byte(s)) {
+		payload = json.RawMessage(s)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, payload, "", "  "); err != nil {
+		return payload
+	}
+	return buf.Bytes()
+}
+
+// writeRecords prints each job as a block of fields, so that indented
+// payloads stay readable.
+func writeRecords(a *app, fields []string, records []record) error {
+	for i, r := range records {
+		if i > 0 {
+			fmt.Fprintln(a.stdout)
+		}
+		for _, f := range fields {
+			if payload, ok := r[f].(json.RawMessage); ok && len(payload) > 0 {
+				fmt.Fprintf(a.stdout, "%s:\n  %s\n", f, strings.ReplaceAll(string(payload), "\n", "\n  "))
+				continue
+			}
+			if _, err := fmt.Fprintf(a.stdout, "%s: %s\n", f, cell(r[f])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Command tsutsu administers the queues and routings of a Fireworq server
+// from a shell.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/stk132/tsutsu"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type command func(a *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"queue": {
+		"list":   queueList,
+		"get":    queueGet,
+		"create": queueCreate,
+		"delete": queueDelete,
+		"stats":  queueStats,
+		"node":   queueNode,
+	},
+	"routing": {
+		"list":   routingList,
+		"get":    routingGet,
+		"create": routingCreate,
+		"delete": routingDelete,
+	},
+	"jobs": {
+		"grabbed":  jobsGrabbed,
+		"waiting":  jobsWaiting,
+		"deferred": jobsDeferred,
+		"failed":   jobsFailed,
+	},
+}
+
+// toplevel are the commands without subcommands.
+var toplevel = map[string]command{
+	"top":      topCommand,
+	"exporter": exporterCommand,
+}
+
+// usageError is reported with the usage of the command and exit status 2.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	url    string
+	output string
+}
+
+func main() {
+	a := &app{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	os.Exit(a.run(os.Args[1:]))
+}
+
+func (a *app) run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		a.usage()
+		return 2
+	}
+
+	if cmd, ok := toplevel[args[0]]; ok {
+		return a.exit(cmd(a, args[1:]))
+	}
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(a.stderr, "tsutsu: unknown command %q\n", args[0])
+		a.usage()
+		return 2
+	}
+	if len(args) < 2 {
+		fmt.Fprintf(a.stderr, "usage: tsutsu %s %s\n", args[0], strings.Join(names(subcommands), "|"))
+		return 2
+	}
+	cmd, ok := subcommands[args[1]]
+	if !ok {
+		fmt.Fprintf(a.stderr, "tsutsu: unknown command %q\n", args[0]+" "+args[1])
+		fmt.Fprintf(a.stderr, "usage: tsutsu %s %s\n", args[0], strings.Join(names(subcommands), "|"))
+		return 2
+	}
+
+	return a.exit(cmd(a, args[2:]))
+}
+
+// exit reports the error of a command and returns the exit status.
+func (a *app) exit(err error) int {
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		if usageErr != "" {
+			fmt.Fprintf(a.stderr, "tsutsu: %s\n", err)
+		}
+		return 2
+	default:
+		fmt.Fprintln(a.stderr, err)
+		return 1
+	}
+}
+
+func (a *app) usage() {
+	fmt.Fprint(a.stderr, `usage: tsutsu <command> [subcommand] [flags] [args]
+
+commands:
+  queue list|get|create|delete|stats|node
+  routing list|get|create|delete
+  jobs grabbed|waiting|deferred|failed
+  top
+  exporter
+
+flags:
+  --url URL         base URL of Fireworq (default $FIREWORQ_URL)
+  --output FORMAT   table, json or yaml (default table)
+`)
+}
+
+func names(subcommands map[string]command) []string {
+	var names []string
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagSet returns the flags of a subcommand with --url and --output
+// registered. argsUsage describes its positional arguments.
+func (a *app) flagSet(name, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("tsutsu "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.StringVar(&a.url, "url", a.getenv("FIREWORQ_URL"), "base URL of Fireworq")
+	fs.StringVar(&a.output, "output", outputTable, "output format: table, json or yaml")
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: tsutsu %s [flags] %s\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags and expects exactly n positional arguments.
+func (a *app) parse(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	return a.parseRange(fs, args, n, n)
+}
+
+func (a *app) parseRange(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		// The flag package has already reported the error with the usage.
+		return nil, usageError("")
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		fs.Usage()
+		return nil, usageError(fmt.Sprintf("%s takes %s argument(s), got %d", fs.Name(), argCount(min, max), fs.NArg()))
+	}
+	switch a.output {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return nil, usageError(fmt.Sprintf("unknown output format %q", a.output))
+	}
+	return fs.Args(), nil
+}
+
+func argCount(min, max int) string {
+	if min == max {
+		return fmt.Sprint(min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
+
+func (a *app) client() (*tsutsu.Tsutsu, error) {
+	if a.url == "" {
+		return nil, usageError("--url or FIREWORQ_URL is required")
+	}
+	return tsutsu.New(a.url, tsutsu.WithUserAgent("tsutsu-cli"))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"strings"
+	"testing"
+)
+
+func runApp(env map[string]string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &stderr, getenv: func(key string) string { return env[key] }}
+	code := a.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	env := map[string]string{"FIREWORQ_URL": s.URL}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "queue create",
+			args:       []string{"queue", "create", "--polling-interval", "100", "--max-workers", "5", "mail"},
+			wantStdout: "NAME  POLLING_INTERVAL  MAX_WORKERS\nmail  100               5\n",
+		},
+		{
+			name:       "queue list",
+			args:       []string{"queue", "list"},
+			wantStdout: "NAME     POLLING_INTERVAL  MAX_WORKERS\ndefault  200               20\nmail     100               5\n",
+		},
+		{
+			name:       "queue get as json",
+			args:       []string{"queue", "get", "--output", "json", "mail"},
+			wantStdout: "{\n  \"name\": \"mail\",\n  \"polling_interval\": 100,\n  \"max_workers\": 5\n}\n",
+		},
+		{
+			name:       "routing create",
+			args:       []string{"routing", "create", "mail.send", "mail"},
+			wantStdout: "JOB_CATEGORY  QUEUE_NAME\nmail.send     mail\n",
+		},
+		{
+			name:       "routing list as yaml",
+			args:       []string{"routing", "list", "--output", "yaml"},
+			wantStdout: "- queue_name: mail\n  job_category: mail.send\n",
+		},
+		{
+			name:       "routing delete",
+			args:       []string{"routing", "delete", "mail.send"},
+			wantStdout: "JOB_CATEGORY  QUEUE_NAME\nmail.send     mail\n",
+		},
+		{
+			name:       "missing routing",
+			args:       []string{"routing", "get", "mail.send"},
+			wantCode:   1,
+			wantStderr: "404",
+		},
+		{
+			name:       "missing argument",
+			args:       []string{"queue", "get"},
+			wantCode:   2,
+			wantStderr: "queue get takes 1 argument(s), got 0",
+		},
+		{
+			name:       "unknown output",
+			args:       []string{"queue", "list", "--output", "xml"},
+			wantCode:   2,
+			wantStderr: `unknown output format "xml"`,
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"queue", "purge"},
+			wantCode:   2,
+			wantStderr: `unknown command "queue purge"`,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			code, stdout, stderr := runApp(env, tt.args...)
+			if code != tt.wantCode {
+				t1.Errorf("run() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr)
+			}
+			if stdout != tt.wantStdout {
+				t1.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t1.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRun_url(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+
+	if code, _, stderr := runApp(nil, "queue", "list"); code != 2 || !strings.Contains(stderr, "FIREWORQ_URL") {
+		t1.Errorf("run() without a URL = %d, %q", code, stderr)
+	}
+	if code, stdout, stderr := runApp(nil, "queue", "list", "--url", s.URL); code != 0 || !strings.Contains(stdout, "default") {
+		t1.Errorf("run() with --url = %d, %q, %q", code, stdout, stderr)
+	}
+}
+
+func TestRun_jobs(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	env := map[string]string{"FIREWORQ_URL": s.URL}
+
+	client := s.Tsutsu()
+	for _, payload := range []interface{}{map[string]int{"n": 1}, `{"n":2}`, map[string]int{"n": 3}} {
+		if _, err := client.PushJob("mail", tsutsu.JobRequest{URL: "http://localhost/", Payload: payload}); err != nil {
+			t1.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "first page",
+			args:       []string{"jobs", "waiting", "--limit", "2", "--asc", "--fields", "id,payload", tsutsutest.DefaultQueueName},
+			wantStdout: "ID  PAYLOAD\n1   {\"n\":1}\n2   \"{\\\"n\\\":2}\"\n",
+			wantStderr: "more jobs: --cursor 3\n",
+		},
+		{
+			name:       "next page",
+			args:       []string{"jobs", "waiting", "--limit", "2", "--asc", "--cursor", "3", "--fields", "id", tsutsutest.DefaultQueueName},
+			wantStdout: "ID\n3\n",
+		},
+		{
+			name:       "all descending",
+			args:       []string{"jobs", "waiting", "--limit", "1", "--all", "--desc", "--fields", "id", tsutsutest.DefaultQueueName},
+			wantStdout: "ID\n3\n2\n1\n",
+		},
+		{
+			name:       "pretty json",
+			args:       []string{"jobs", "waiting", "--limit", "2", "--asc", "--cursor", "2", "--fields", "id,payload", "--pretty", "--output", "json", tsutsutest.DefaultQueueName},
+			wantStdout: "{\n  \"jobs\": [\n    {\n      \"id\": 2,\n      \"payload\": {\n        \"n\": 2\n      }\n    },\n    {\n      \"id\": 3,\n      \"payload\": {\n        \"n\": 3\n      }\n    }\n  ],\n  \"next_cursor\": \"\"\n}\n",
+		},
+		{
+			name:       "pretty table",
+			args:       []string{"jobs", "waiting", "--asc", "--cursor", "3", "--fields", "id,payload", "--pretty", tsutsutest.DefaultQueueName},
+			wantStdout: "id: 3\npayload:\n  {\n    \"n\": 3\n  }\n",
+		},
+		{
+			name:       "empty failed list",
+			args:       []string{"jobs", "failed", "--output", "yaml", tsutsutest.DefaultQueueName},
+			wantStdout: "failed_jobs: []\nnext_cursor: \"\"\n",
+		},
+		{
+			name:       "unknown field",
+			args:       []string{"jobs", "failed", "--fields", "id,status", tsutsutest.DefaultQueueName},
+			wantCode:   2,
+			wantStderr: `unknown field "status"`,
+		},
+		{
+			name:       "zero limit",
+			args:       []string{"jobs", "waiting", "--limit", "0", tsutsutest.DefaultQueueName},
+			wantCode:   2,
+			wantStderr: "--limit must be positive",
+		},
+		{
+			name:       "asc and desc",
+			args:       []string{"jobs", "grabbed", "--asc", "--desc", tsutsutest.DefaultQueueName},
+			wantCode:   2,
+			wantStderr: "--asc and --desc cannot be used together",
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			code, stdout, stderr := runApp(env, tt.args...)
+			if code != tt.wantCode {
+				t1.Errorf("run() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr)
+			}
+			if stdout != tt.wantStdout {
+				t1.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t1.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"sort"
+)
+
+func queueTable(queues ...model.Queue) *table {
+	t := newTable("NAME", "POLLING_INTERVAL", "MAX_WORKERS")
+	for _, q := range queues {
+		t.add(q.Name, q.PollingInterval, q.MaxWorkers)
+	}
+	return t
+}
+
+func queueList(a *app, args []string) error {
+	fs := a.flagSet("queue list", "")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	queues, err := client.Queues()
+	if err != nil {
+		return err
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i].Name < queues[j].Name })
+	return a.print(queues, queueTable(queues...))
+}
+
+func queueGet(a *app, args []string) error {
+	fs := a.flagSet("queue get", "NAME")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	q, err := client.Queue(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(q, queueTable(q))
+}
+
+func queueCreate(a *app, args []string) error {
+	fs := a.flagSet("queue create", "NAME")
+	var spec tsutsu.QueueSpec
+	fs.UintVar(&spec.PollingInterval, "polling-interval", 200, "polling interval in milliseconds")
+	fs.UintVar(&spec.MaxWorkers, "max-workers", 20, "maximum number of workers")
+	fs.Float64Var(&spec.MaxDispatchesPerSecond, "max-dispatches-per-second", 0, "dispatch rate limit, requires Fireworq 1.5.0 (0 for unlimited)")
+	fs.UintVar(&spec.MaxBurstSize, "max-burst-size", 0, "burst size of the dispatch rate limit")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	spec.Name = args[0]
+	spec, err = client.PutQueue(spec)
+	if err != nil {
+		return err
+	}
+	return a.print(spec, queueTable(spec.Queue()))
+}
+
+func queueDelete(a *app, args []string) error {
+	fs := a.flagSet("queue delete", "NAME")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	q, err := client.DeleteQueue(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(q, queueTable(q))
+}
+
+func statsTable(names []string, stats map[string]tsutsu.QueueStats) *table {
+	t := newTable("QUEUE", "PUSHES/S", "POPS/S", "PUSHES", "POPS", "SUCCESSES", "FAILURES", "PERMANENT_FAILURES", "WORKERS", "IDLE", "NODES")
+	for _, name := range names {
+		s := stats[name]
+		t.add(name, s.PushesPerSecond, s.PopPerSecond, s.TotalPushes, s.TotalPops, s.TotalSuccesses, s.TotalFailures, s.TotalPermanentFailures, s.TotalWorkers, s.IdleWorkers, s.ActiveNodes)
+	}
+	return t
+}
+
+// queueStats shows the stats of one queue, or of every queue without NAME.
+func queueStats(a *app, args []string) error {
+	fs := a.flagSet("queue stats", "[NAME]")
+	args, err := a.parseRange(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		stats, err := client.Stats(args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(stats, statsTable(args, map[string]tsutsu.QueueStats{args[0]: stats}))
+	}
+
+	stats, err := client.AllStats()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return a.print(stats, statsTable(names, stats))
+}
+
+func queueNode(a *app, args []string) error {
+	fs := a.flagSet("queue node", "NAME")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	node, err := client.Node(args[0])
+	if err != nil {
+		return err
+	}
+	t := newTable("ID", "HOST")
+	t.add(node.ID, node.Host)
+	return a.print(node, t)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"github.com/fireworq/fireworq/model"
+	"sort"
+)
+
+func routingTable(routings ...model.Routing) *table {
+	t := newTable("JOB_CATEGORY", "QUEUE_NAME")
+	for _, rt := range routings {
+		t.add(rt.JobCategory, rt.QueueName)
+	}
+	return t
+}
+
+func routingList(a *app, args []string) error {
+	fs := a.flagSet("routing list", "")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	routings, err := client.Routings()
+	if err != nil {
+		return err
+	}
+	sort.Slice(routings, func(i, j int) bool { return routings[i].JobCategory < routings[j].JobCategory })
+	return a.print(routings, routingTable(routings...))
+}
+
+func routingGet(a *app, args []string) error {
+	fs := a.flagSet("routing get", "JOB_CATEGORY")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	rt, err := client.Routing(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(rt, routingTable(rt))
+}
+
+func routingCreate(a *app, args []string) error {
+	fs := a.flagSet("routing create", "JOB_CATEGORY QUEUE_NAME")
+	args, err := a.parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	rt, err := client.CreateRouting(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return a.print(rt, routingTable(rt))
+}
+
+func routingDelete(a *app, args []string) error {
+	fs := a.flagSet("routing delete", "JOB_CATEGORY")
+	args, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	rt, err := client.DeleteRouting(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(rt, routingTable(rt))
+}
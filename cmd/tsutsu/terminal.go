@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// rawTerminal switches the terminal off line buffering and echo with stty,
+// so that keys are read as they are typed, and returns a function restoring
+// the previous settings.
+func rawTerminal() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return func() {}, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return func() {}, err
+	}
+	return func() { stty(strings.TrimSpace(state)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
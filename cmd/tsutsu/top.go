@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/stk132/tsutsu"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyEnter = "enter"
+	keyBack  = "back"
+)
+
+// top is the state of the dashboard. It is changed by keys and by the
+// updates fetched from the server on every tick, and rendered as a whole
+// after each.
+type top struct {
+	client tsutsu.Client
+	limit  uint
+
+	queues    []string
+	stats     map[string]tsutsu.QueueStats
+	previous  map[string]tsutsu.QueueStats
+	selected  int
+	updatedAt time.Time
+	err       error
+
+	// The queue whose jobs are shown, or "" for the overview.
+	queue   string
+	list    jobList
+	cursors []string
+	records []record
+	next    string
+
+	// seq changes with the queue, list or page shown, so that updates
+	// fetched for another one are dropped.
+	seq int
+}
+
+// update is what a fetch got from the server.
+type update struct {
+	seq     int
+	queues  []string
+	stats   map[string]tsutsu.QueueStats
+	records []record
+	next    string
+	err     error
+	at      time.Time
+}
+
+func newTop(client tsutsu.Client, limit uint) *top {
+	return &top{client: client, limit: limit, list: waitingJobs}
+}
+
+// fetch returns a function fetching what the dashboard shows now. It only
+// captures the state it needs, so that it can run while keys change t.
+func (t *top) fetch() func(context.Context) update {
+	client, limit, seq := t.client, t.limit, t.seq
+	queue, list, cursor := t.queue, t.list, ""
+	if queue != "" {
+		cursor = t.cursors[len(t.cursors)-1]
+	}
+
+	return func(ctx context.Context) update {
+		u := update{seq: seq}
+		u.queues, u.stats, u.err = fetchStats(ctx, client)
+		if u.err == nil && queue != "" {
+			inspection := client.Jobs().Limit(limit)
+			if cursor != "" {
+				inspection = inspection.Cursor(cursor)
+			}
+			u.records, u.next, u.err = list.page(ctx, inspection, queue)
+		}
+		u.at = time.Now()
+		return u
+	}
+}
+
+func fetchStats(ctx context.Context, client tsutsu.Client) ([]string, map[string]tsutsu.QueueStats, error) {
+	queues, err := client.QueuesWithContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats, err := client.AllStatsWithContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(queues))
+	for _, q := range queues {
+		names = append(names, q.Name)
+	}
+	sort.Strings(names)
+	return names, stats, nil
+}
+
+func (t *top) apply(u update) {
+	if u.seq != t.seq {
+		return
+	}
+	t.err, t.updatedAt = u.err, u.at
+
+	if u.stats != nil {
+		t.queues = u.queues
+		if t.selected >= len(t.queues) {
+			t.selected = len(t.queues) - 1
+		}
+		if t.selected < 0 {
+			t.selected = 0
+		}
+		if t.stats != nil {
+			t.previous = t.stats
+		}
+		t.stats = u.stats
+	}
+	if u.err == nil && t.queue != "" {
+		t.records, t.next = u.records, u.next
+	}
+}
+
+// handle applies a key and reports whether the dashboard should quit.
+func (t *top) handle(key string) bool {
+	switch key {
+	case "q":
+		return true
+	case keyUp, "k":
+		if t.queue == "" && t.selected > 0 {
+			t.selected--
+		}
+	case keyDown, "j":
+		if t.queue == "" && t.selected < len(t.queues)-1 {
+			t.selected++
+		}
+	case keyEnter:
+		if t.queue == "" && len(t.queues) > 0 {
+			t.open(t.queues[t.selected], waitingJobs)
+		}
+	case keyBack, "b":
+		if t.queue != "" {
+			t.queue = ""
+			t.seq++
+		}
+	case "w":
+		if t.queue != "" {
+			t.open(t.queue, waitingJobs)
+		}
+	case "f":
+		if t.queue != "" {
+			t.open(t.queue, failedJobs)
+		}
+	case "n":
+		if t.queue != "" && t.next != "" {
+			t.cursors = append(t.cursors, t.next)
+			t.records, t.next = nil, ""
+			t.seq++
+		}
+	case "p":
+		if t.queue != "" && len(t.cursors) > 1 {
+			t.cursors = t.cursors[:len(t.cursors)-1]
+			t.records, t.next = nil, ""
+			t.seq++
+		}
+	}
+	return false
+}
+
+func (t *top) open(queue string, list jobList) {
+	t.queue, t.list, t.cursors = queue, list, []string{""}
+	t.records, t.next = nil, ""
+	t.seq++
+}
+
+// run shows the dashboard on w until q is pressed, keys is closed or a
+// signal arrives. Fetches run in the background, one at a time and each
+// bounded by interval, so that a hanging server does not block keys and
+// signals.
+func (t *top) run(w io.Writer, keys <-chan string, signals <-chan os.Signal, interval time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan update, 1)
+	fetching := false
+	refresh := func() {
+		if fetching {
+			return
+		}
+		fetching = true
+		fetch := t.fetch()
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, interval)
+			defer cancel()
+			updates <- fetch(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	refresh()
+	for {
+		fmt.Fprint(w, "\x1b[H\x1b[2J")
+		if err := t.render(w); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+			refresh()
+		case u := <-updates:
+			fetching = false
+			t.apply(u)
+			if u.seq != t.seq {
+				refresh()
+			}
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			seq := t.seq
+			if t.handle(key) {
+				return nil
+			}
+			if t.seq != seq {
+				refresh()
+			}
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+func (t *top) render(w io.Writer) error {
+	var b strings.Builder
+	updated := "loading"
+	if !t.updatedAt.IsZero() {
+		updated = t.updatedAt.Format("15:04:05")
+	}
+	fmt.Fprintf(&b, "tsutsu top - %s\n", updated)
+	if t.err != nil {
+		fmt.Fprintf(&b, "error: %s\n", t.err)
+	}
+	b.WriteString("\n")
+	if t.queue == "" {
+		t.renderQueues(&b)
+		b.WriteString("\nj/k: select  enter: jobs  q: quit\n")
+	} else {
+		t.renderJobs(&b)
+		b.WriteString("\nw: waiting  f: failed  n/p: next/previous page  b: back  q: quit\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (t *top) renderQueues(w io.Writer) {
+	tbl := newTable("QUEUE", "PUSHES/S", "POPS/S", "ACTIVE", "IDLE", "FAILURES", "PERMANENT", "BACKLOG", "DELTA")
+	for _, name := range t.queues {
+		s := t.stats[name]
+		delta := "-"
+		if prev, ok := t.previous[name]; ok {
+			delta = fmt.Sprintf("%+d", backlog(s)-backlog(prev))
+		}
+		tbl.add(name, s.PushesPerSecond, s.PopPerSecond, s.TotalWorkers-s.IdleWorkers, s.IdleWorkers, s.TotalFailures, s.TotalPermanentFailures, backlog(s), delta)
+	}
+
+	var b strings.Builder
+	tbl.write(&b)
+	for i, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if i == t.selected+1 {
+			fmt.Fprintf(w, "\x1b[7m%s\x1b[0m\n", line)
+			continue
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// backlog is the number of jobs pushed but not completed yet, which includes
+// running jobs and jobs waiting for a retry.
+func backlog(s tsutsu.QueueStats) int64 {
+	return s.TotalPushes - s.TotalCompletes
+}
+
+func (t *top) renderJobs(w io.Writer) {
+	fmt.Fprintf(w, "%s jobs of %s (page %d)\n\n", t.list.name, t.queue, len(t.cursors))
+
+	header := make([]string, len(t.list.defaultFields))
+	for i, f := range t.list.defaultFields {
+		header[i] = strings.ToUpper(f)
+	}
+	tbl := newTable(header...)
+	for _, r := range t.records {
+		row := make([]interface{}, len(t.list.defaultFields))
+		for i, f := range t.list.defaultFields {
+			row[i] = cell(r[f])
+		}
+		tbl.add(row...)
+	}
+	tbl.write(w)
+	if len(t.records) == 0 {
+		fmt.Fprintln(w, "no jobs")
+	}
+}
+
+func topCommand(a *app, args []string) error {
+	fs := a.flagSet("top", "")
+	interval := fs.Duration("interval", 2*time.Second, "refresh interval")
+	limit := fs.Uint("limit", 20, "number of jobs per page when browsing a queue")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return usageError("--interval must be positive")
+	}
+	if *limit == 0 {
+		return usageError("--limit must be positive")
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	restore, err := rawTerminal()
+	raw := err == nil
+	defer restore()
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, raw, keys)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	// Use the alternate screen and hide the cursor while running.
+	fmt.Fprint(a.stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(a.stdout, "\x1b[?25h\x1b[?1049l")
+
+	return newTop(client, *limit).run(a.stdout, keys, signals, *interval)
+}
+
+// readKeys sends the keys typed on r. Without a raw terminal every line is
+// a key, and an empty line is enter.
+func readKeys(r io.Reader, raw bool, keys chan<- string) {
+	defer close(keys)
+	reader := bufio.NewReader(r)
+
+	if !raw {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				line = keyEnter
+			}
+			keys <- line
+		}
+	}
+
+	for {
+		c, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		switch c {
+		case '\r', '\n':
+			keys <- keyEnter
+		case 0x1b:
+			// Arrow keys arrive as ESC [ A and ESC [ B, a lone ESC is back.
+			if reader.Buffered() >= 2 {
+				seq := make([]byte, 2)
+				reader.Read(seq)
+				switch string(seq) {
+				case "[A":
+					keys <- keyUp
+				case "[B":
+					keys <- keyDown
+				}
+				continue
+			}
+			keys <- keyBack
+		default:
+			keys <- string(c)
+		}
+	}
+}
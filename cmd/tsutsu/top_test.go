@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTop(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	client := s.Tsutsu()
+
+	if _, err := client.CreateQueue("mail", 100, 4); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := client.CreateRouting("mail.send", "mail"); err != nil {
+		t1.Fatal(err)
+	}
+	push := func() uint64 {
+		result, err := client.PushJob("mail.send", tsutsu.JobRequest{URL: "http://localhost/"})
+		if err != nil {
+			t1.Fatal(err)
+		}
+		return result.ID
+	}
+
+	render := func(t *top) string {
+		var b bytes.Buffer
+		if err := t.render(&b); err != nil {
+			t1.Fatal(err)
+		}
+		return b.String()
+	}
+	row := func(screen, queue string) []string {
+		for _, line := range strings.Split(screen, "\n") {
+			fields := strings.Fields(strings.NewReplacer("\x1b[7m", "", "\x1b[0m", "").Replace(line))
+			if len(fields) > 0 && fields[0] == queue {
+				return fields
+			}
+		}
+		t1.Fatalf("no row for %s in %s", queue, screen)
+		return nil
+	}
+
+	t := newTop(client, 10)
+	refresh := func() { t.apply(t.fetch()(context.Background())) }
+	push()
+	refresh()
+	if got := row(render(t), "mail"); strings.Join(got[3:], " ") != "0 4 0 0 1 -" {
+		t1.Errorf("first row = %v, want 0 active, 4 idle, backlog 1 and no delta", got)
+	}
+
+	grabbed := push()
+	if err := s.Grab("mail", grabbed); err != nil {
+		t1.Fatal(err)
+	}
+	failed := push()
+	if _, err := s.Fail("mail", failed, tsutsu.JobResult{Status: "failure", Code: 500, Message: "boom"}); err != nil {
+		t1.Fatal(err)
+	}
+	refresh()
+	if got := row(render(t), "mail"); strings.Join(got[3:], " ") != "1 3 1 1 2 +1" {
+		t1.Errorf("second row = %v, want 1 active, 3 idle, 1 failure and a backlog of 2 (+1)", got)
+	}
+
+	// The queues are sorted, so mail is the second one.
+	for _, key := range []string{keyDown, keyEnter} {
+		if t.handle(key) {
+			t1.Fatalf("handle(%q) quit", key)
+		}
+	}
+	refresh()
+	screen := render(t)
+	if !strings.Contains(screen, "waiting jobs of mail") || !strings.Contains(screen, "mail.send") {
+		t1.Errorf("waiting jobs screen = %s", screen)
+	}
+
+	t.handle("f")
+	refresh()
+	screen = render(t)
+	if !strings.Contains(screen, "failed jobs of mail") || !strings.Contains(screen, "failure 500 boom") {
+		t1.Errorf("failed jobs screen = %s", screen)
+	}
+
+	t.handle(keyBack)
+	if screen := render(t); !strings.Contains(screen, "QUEUE") {
+		t1.Errorf("overview screen = %s", screen)
+	}
+	if !t.handle("q") {
+		t1.Error("handle(q) should quit")
+	}
+}
+
+func TestTop_run_slowServer(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	s.SetLatency(time.Minute)
+
+	tests := []struct {
+		name string
+		quit func(keys chan<- string, signals chan<- os.Signal)
+	}{
+		{name: "q", quit: func(keys chan<- string, _ chan<- os.Signal) { keys <- "q" }},
+		{name: "signal", quit: func(_ chan<- string, signals chan<- os.Signal) { signals <- syscall.SIGINT }},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			keys := make(chan string)
+			signals := make(chan os.Signal)
+			var out bytes.Buffer
+			done := make(chan error, 1)
+			go func() { done <- newTop(s.Tsutsu(), 10).run(&out, keys, signals, 50*time.Millisecond) }()
+
+			// Let the first fetch run into its deadline.
+			time.Sleep(200 * time.Millisecond)
+			quit := make(chan struct{})
+			go func() {
+				tt.quit(keys, signals)
+				close(quit)
+			}()
+			select {
+			case <-quit:
+			case <-time.After(time.Second):
+				t1.Fatal("the dashboard did not take input while the server hung")
+			}
+			select {
+			case err := <-done:
+				if err != nil {
+					t1.Fatal(err)
+				}
+			case <-time.After(time.Second):
+				t1.Fatal("the dashboard did not quit while the server hung")
+			}
+			if !strings.Contains(out.String(), "deadline exceeded") {
+				t1.Errorf("screen = %q, want the fetch to time out", out.String())
+			}
+		})
+	}
+}
+
+func TestReadKeys(t1 *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		raw   bool
+		want  []string
+	}{
+		{name: "raw", input: "j\x1b[A\x1b[Bq\r", raw: true, want: []string{"j", keyUp, keyDown, "q", keyEnter}},
+		{name: "lines", input: "j\n\nq\n", raw: false, want: []string{"j", keyEnter, "q"}},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			keys := make(chan string)
+			go readKeys(strings.NewReader(tt.input), tt.raw, keys)
+			var got []string
+			for key := range keys {
+				got = append(got, key)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t1.Errorf("readKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_topFlags(t1 *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{name: "zero interval", args: []string{"top", "--interval", "0s"}, wantStderr: "--interval must be positive"},
+		{name: "negative interval", args: []string{"top", "--interval", "-1s"}, wantStderr: "--interval must be positive"},
+		{name: "zero limit", args: []string{"top", "--limit", "0"}, wantStderr: "--limit must be positive"},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			code, stdout, stderr := runApp(map[string]string{"FIREWORQ_URL": "http://localhost"}, tt.args...)
+			if code != 2 || stdout != "" || !strings.Contains(stderr, tt.wantStderr) {
+				t1.Errorf("run() = %d, %q, %q, want 2 and %q", code, stdout, stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package tsutsu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fireworq/fireworq/model"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ConfigFormat string
+
+const (
+	ConfigYAML ConfigFormat = "yaml"
+	ConfigJSON ConfigFormat = "json"
+)
+
+func ConfigFormatFromPath(path string) (ConfigFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ConfigYAML, nil
+	case ".json":
+		return ConfigJSON, nil
+	}
+	return "", fmt.Errorf("tsutsu: cannot tell the config format of %s from its extension", path)
+}
+
+// Config is the queue and routing topology kept in a config file:
+//
+//	queues:
+//	  - name: mail
+//	    polling_interval: 200
+//	    max_workers: 10
+//	    max_dispatches_per_second: 5
+//	    max_burst_size: 10
+//	routings:
+//	  - job_category: mail.send
+//	    queue_name: mail
+//
+// JSON files use the same structure. Routings may refer to queues the file
+// does not declare, such as the default queue of the server.
+type Config struct {
+	Queues   []QueueSpec
+	Routings []model.Routing
+}
+
+// Topology returns the queues and routings of the config, throttling
+// settings included, for a Reconciler.
+func (c Config) Topology() Topology {
+	return Topology{
+		Queues:   append([]QueueSpec(nil), c.Queues...),
+		Routings: append([]model.Routing(nil), c.Routings...),
+	}
+}
+
+// ExportConfig snapshots the queues and routings of a server, sorted by name,
+// with the throttling settings of the queues.
+func ExportConfig(ctx context.Context, client Client) (Config, error) {
+	queues, err := client.QueueSpecsWithContext(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+	routings, err := client.RoutingsWithContext(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var c Config
+	c.Queues = sortedQueues(queues)
+	c.Routings = sortedRoutings(routings)
+	return c, nil
+}
+
+type configFile struct {
+	Queues   []configQueue   `json:"queues" yaml:"queues"`
+	Routings []configRouting `json:"routings" yaml:"routings"`
+}
+
+type configQueue struct {
+	Name                   string  `json:"name" yaml:"name"`
+	PollingInterval        uint    `json:"polling_interval" yaml:"polling_interval"`
+	MaxWorkers             uint    `json:"max_workers" yaml:"max_workers"`
+	MaxDispatchesPerSecond float64 `json:"max_dispatches_per_second,omitempty" yaml:"max_dispatches_per_second,omitempty"`
+	MaxBurstSize           uint    `json:"max_burst_size,omitempty" yaml:"max_burst_size,omitempty"`
+}
+
+type configRouting struct {
+	JobCategory string `json:"job_category" yaml:"job_category"`
+	QueueName   string `json:"queue_name" yaml:"queue_name"`
+}
+
+// Marshal renders the config in the format ParseConfig reads. Queues and
+// routings are sorted by name so that the output of two servers can be
+// compared line by line.
+func (c Config) Marshal(format ConfigFormat) ([]byte, error) {
+	file := configFile{Queues: []configQueue{}, Routings: []configRouting{}}
+	queues := append([]QueueSpec(nil), c.Queues...)
+	sort.Slice(queues, func(i, j int) bool { return queues[i].Name < queues[j].Name })
+	for _, q := range queues {
+		file.Queues = append(file.Queues, configQueue(q))
+	}
+	for _, rt := range sortedRoutings(c.Routings) {
+		file.Routings = append(file.Routings, configRouting{JobCategory: rt.JobCategory, QueueName: rt.QueueName})
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case ConfigYAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(file); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	case ConfigJSON:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("tsutsu: unknown config format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+func SaveConfig(path string, c Config) error {
+	format, err := ConfigFormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := c.Marshal(format)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Validate checks a Config built in Go. Configs returned by ParseConfig and
+// LoadConfig are already validated.
+func (c Config) Validate() error {
+	return c.validate(nil, nil).err()
+}
+
+type ConfigError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
+
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e ConfigErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e ConfigErrors) withFile(file string) ConfigErrors {
+	for i := range e {
+		e[i].File = file
+	}
+	return e
+}
+
+func LoadConfig(path string) (Config, error) {
+	format, err := ConfigFormatFromPath(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	c, err := ParseConfig(data, format)
+	var errs ConfigErrors
+	if errors.As(err, &errs) {
+		return Config{}, errs.withFile(path)
+	}
+	return c, err
+}
+
+func ParseConfig(data []byte, format ConfigFormat) (Config, error) {
+	switch format {
+	case ConfigJSON:
+		if err := checkJSONSyntax(data); err != nil {
+			return Config{}, ConfigErrors{*err}
+		}
+	case ConfigYAML:
+	default:
+		return Config{}, fmt.Errorf("tsutsu: unknown config format %q", format)
+	}
+
+	// JSON is parsed as YAML too, which keeps the line numbers of every node.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Config{}, ConfigErrors{yamlError(err)}
+	}
+
+	var p configParser
+	c := p.parse(&doc)
+	p.errs = append(p.errs, c.validate(p.queueLines, p.routingLines)...)
+	if len(p.errs) > 0 {
+		return Config{}, p.errs
+	}
+	return c, nil
+}
+
+func checkJSONSyntax(data []byte) *ConfigError {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+		return &ConfigError{Line: line, Message: syntaxErr.Error()}
+	}
+	return &ConfigError{Message: err.Error()}
+}
+
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+func yamlError(err error) ConfigError {
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return ConfigError{Line: line, Message: m[2]}
+	}
+	return ConfigError{Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+}
+
+type configParser struct {
+	errs         ConfigErrors
+	queueLines   []int
+	routingLines []int
+}
+
+func (p *configParser) errorf(node *yaml.Node, format string, args ...interface{}) {
+	p.errs = append(p.errs, ConfigError{Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *configParser) parse(doc *yaml.Node) Config {
+	var c Config
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return c
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		p.errorf(root, "the top level must be a mapping with queues and routings")
+		return c
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "queues":
+			for _, item := range p.sequence(key.Value, value) {
+				if q, ok := p.queue(item); ok {
+					c.Queues = append(c.Queues, q)
+					p.queueLines = append(p.queueLines, item.Line)
+				}
+			}
+		case "routings":
+			for _, item := range p.sequence(key.Value, value) {
+				if rt, ok := p.routing(item); ok {
+					c.Routings = append(c.Routings, rt)
+					p.routingLines = append(p.routingLines, item.Line)
+				}
+			}
+		default:
+			p.errorf(key, "unknown key %q", key.Value)
+		}
+	}
+	return c
+}
+
+func (p *configParser) sequence(name string, node *yaml.Node) []*yaml.Node {
+	if node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		p.errorf(node, "%s must be a list", name)
+		return nil
+	}
+	return node.Content
+}
+
+func (p *configParser) fields(node *yaml.Node, what string, decode func(key string, value *yaml.Node) bool) bool {
+	if node.Kind != yaml.MappingNode {
+		p.errorf(node, "%s must be a mapping", what)
+		return false
+	}
+
+	ok := true
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !decode(key.Value, value) {
+			p.errorf(key, "unknown key %q in %s", key.Value, what)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (p *configParser) scalar(key string, value *yaml.Node, v interface{}, kind string) {
+	if value.Kind != yaml.ScalarNode || value.Decode(v) != nil {
+		p.errorf(value, "%s must be %s", key, kind)
+	}
+}
+
+func (p *configParser) queue(node *yaml.Node) (QueueSpec, bool) {
+	var q QueueSpec
+	before := len(p.errs)
+	ok := p.fields(node, "queue", func(key string, value *yaml.Node) bool {
+		switch key {
+		case "name":
+			p.scalar(key, value, &q.Name, "a string")
+		case "polling_interval":
+			p.scalar(key, value, &q.PollingInterval, "a non-negative integer")
+		case "max_workers":
+			p.scalar(key, value, &q.MaxWorkers, "a non-negative integer")
+		case "max_dispatches_per_second":
+			p.scalar(key, value, &q.MaxDispatchesPerSecond, "a number")
+		case "max_burst_size":
+			p.scalar(key, value, &q.MaxBurstSize, "a non-negative integer")
+		default:
+			return false
+		}
+		return true
+	})
+	return q, ok && len(p.errs) == before
+}
+
+func (p *configParser) routing(node *yaml.Node) (model.Routing, bool) {
+	var rt model.Routing
+	before := len(p.errs)
+	ok := p.fields(node, "routing", func(key string, value *yaml.Node) bool {
+		switch key {
+		case "job_category":
+			p.scalar(key, value, &rt.JobCategory, "a string")
+		case "queue_name":
+			p.scalar(key, value, &rt.QueueName, "a string")
+		default:
+			return false
+		}
+		return true
+	})
+	return rt, ok && len(p.errs) == before
+}
+
+func lineAt(lines []int, i int) int {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return 0
+}
+
+func (c Config) validate(queueLines, routingLines []int) ConfigErrors {
+	var errs ConfigErrors
+	errorf := func(line int, format string, args ...interface{}) {
+		errs = append(errs, ConfigError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	queues := map[string]bool{}
+	for i, q := range c.Queues {
+		line := lineAt(queueLines, i)
+		if err := validateQueueName(q.Name); err != nil {
+			errorf(line, "%s", strings.TrimPrefix(err.Error(), "tsutsu: "))
+			continue
+		}
+		if queues[q.Name] {
+			errorf(line, "queue %s is defined more than once", q.Name)
+		}
+		queues[q.Name] = true
+
+		if q.PollingInterval == 0 {
+			errorf(line, "queue %s: polling_interval must be positive", q.Name)
+		}
+		if q.MaxWorkers == 0 {
+			errorf(line, "queue %s: max_workers must be positive", q.Name)
+		}
+		if q.MaxDispatchesPerSecond < 0 {
+			errorf(line, "queue %s: max_dispatches_per_second must not be negative", q.Name)
+		}
+		if q.MaxBurstSize > 0 && q.MaxDispatchesPerSecond == 0 {
+			errorf(line, "queue %s: max_burst_size requires max_dispatches_per_second", q.Name)
+		}
+	}
+
+	categories := map[string]bool{}
+	for i, rt := range c.Routings {
+		line := lineAt(routingLines, i)
+		if err := validateJobCategory(rt.JobCategory); err != nil {
+			errorf(line, "%s", strings.TrimPrefix(err.Error(), "tsutsu: "))
+			continue
+		}
+		if categories[rt.JobCategory] {
+			errorf(line, "routing %s is defined more than once", rt.JobCategory)
+		}
+		categories[rt.JobCategory] = true
+
+		// Whether the queue exists is left to Reconciler.Plan, which knows
+		// the default queue of the server.
+		if err := validateQueueName(rt.QueueName); err != nil {
+			errorf(line, "routing %s: %s", rt.JobCategory, strings.TrimPrefix(err.Error(), "tsutsu: "))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,289 @@
+package tsutsu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fireworq/fireworq/model"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConfigYAML = `queues:
+  - name: mail
+    polling_interval: 200
+    max_workers: 10
+    max_dispatches_per_second: 5
+    max_burst_size: 10
+  - name: default
+    polling_interval: 100
+    max_workers: 20
+routings:
+  - job_category: mail.send
+    queue_name: mail
+`
+
+const testConfigJSON = `{
+  "queues": [
+    {"name": "mail", "polling_interval": 200, "max_workers": 10, "max_dispatches_per_second": 5, "max_burst_size": 10},
+    {"name": "default", "polling_interval": 100, "max_workers": 20}
+  ],
+  "routings": [
+    {"job_category": "mail.send", "queue_name": "mail"}
+  ]
+}
+`
+
+func TestParseConfig(t1 *testing.T) {
+	want := Config{
+		Queues: []QueueSpec{
+			{Name: "mail", PollingInterval: 200, MaxWorkers: 10, MaxDispatchesPerSecond: 5, MaxBurstSize: 10},
+			{Name: "default", PollingInterval: 100, MaxWorkers: 20},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "mail.send", QueueName: "mail"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		format ConfigFormat
+	}{
+		{name: "yaml", data: testConfigYAML, format: ConfigYAML},
+		{name: "json", data: testConfigJSON, format: ConfigJSON},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			got, err := ParseConfig([]byte(tt.data), tt.format)
+			if err != nil {
+				t1.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t1.Errorf("ParseConfig() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestConfig_Topology(t1 *testing.T) {
+	c, err := ParseConfig([]byte(testConfigYAML), ConfigYAML)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	want := Topology{
+		Queues: []QueueSpec{
+			{Name: "mail", PollingInterval: 200, MaxWorkers: 10, MaxDispatchesPerSecond: 5, MaxBurstSize: 10},
+			{Name: "default", PollingInterval: 100, MaxWorkers: 20},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "mail.send", QueueName: "mail"},
+		},
+	}
+	if got := c.Topology(); !reflect.DeepEqual(got, want) {
+		t1.Errorf("Topology() = %v, want %v", got, want)
+	}
+}
+
+func TestParseConfig_errors(t1 *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format ConfigFormat
+		want   []string
+	}{
+		{
+			name:   "yaml syntax",
+			data:   "queues:\n  - name: mail\n    name: x: y\n",
+			format: ConfigYAML,
+			want:   []string{"line 3: "},
+		},
+		{
+			name:   "json syntax",
+			data:   "{\n  \"queues\": [\n    {\"name\": \"mail\",}\n  ]\n}\n",
+			format: ConfigJSON,
+			want:   []string{"line 3: "},
+		},
+		{
+			name:   "unknown key",
+			data:   "queues:\n  - name: mail\n    polling_interval: 1\n    max_worker: 1\n",
+			format: ConfigYAML,
+			want:   []string{`line 4: unknown key "max_worker" in queue`},
+		},
+		{
+			name:   "wrong type",
+			data:   "queues:\n  - name: mail\n    polling_interval: fast\n    max_workers: 1\n",
+			format: ConfigYAML,
+			want:   []string{"line 3: polling_interval must be a non-negative integer"},
+		},
+		{
+			name:   "invalid values",
+			data:   "queues:\n  - name: mail\n    polling_interval: 0\n    max_workers: 0\n  - name: a/b\n    polling_interval: 1\n    max_workers: 1\n",
+			format: ConfigYAML,
+			want: []string{
+				"line 2: queue mail: polling_interval must be positive",
+				"line 2: queue mail: max_workers must be positive",
+				`line 5: invalid name: queue name "a/b" must not contain '/'`,
+			},
+		},
+		{
+			name:   "duplicates",
+			data:   "queues:\n  - name: mail\n    polling_interval: 1\n    max_workers: 1\n  - name: mail\n    polling_interval: 1\n    max_workers: 1\nroutings:\n  - job_category: a\n    queue_name: mail\n  - job_category: a\n    queue_name: other\n",
+			format: ConfigYAML,
+			want: []string{
+				"line 5: queue mail is defined more than once",
+				"line 11: routing a is defined more than once",
+			},
+		},
+		{
+			name:   "routings to invalid queues",
+			data:   "queues:\n  - name: mail\n    polling_interval: 0\n    max_workers: 1\nroutings:\n  - job_category: a\n    queue_name: mail\n  - job_category: b\n    queue_name: a/b\n",
+			format: ConfigYAML,
+			want: []string{
+				"line 2: queue mail: polling_interval must be positive",
+				`line 8: routing b: invalid name: queue name "a/b" must not contain '/'`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			_, err := ParseConfig([]byte(tt.data), tt.format)
+			var errs ConfigErrors
+			if !errors.As(err, &errs) {
+				t1.Fatalf("ParseConfig() error = %v, want ConfigErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t1.Fatalf("ParseConfig() error = %v, want %d errors", err, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(errs[i].Error(), want) {
+					t1.Errorf("errors[%d] = %q, want prefix %q", i, errs[i].Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseConfig_defaultQueue(t1 *testing.T) {
+	data := "queues:\n  - name: mail\n    polling_interval: 200\n    max_workers: 10\nroutings:\n  - job_category: mail.send\n    queue_name: mail\n  - job_category: report.daily\n    queue_name: default\n"
+	c, err := ParseConfig([]byte(data), ConfigYAML)
+	if err != nil {
+		t1.Fatalf("ParseConfig() error = %v, want routings to the undeclared default queue accepted", err)
+	}
+	if len(c.Routings) != 2 || c.Routings[1].QueueName != "default" {
+		t1.Errorf("Routings = %v", c.Routings)
+	}
+}
+
+func TestLoadConfig(t1 *testing.T) {
+	dir, err := ioutil.TempDir("", "tsutsu")
+	if err != nil {
+		t1.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "topology.yml")
+	if err := ioutil.WriteFile(path, []byte("queues:\n  - name: mail\n"), 0644); err != nil {
+		t1.Fatal(err)
+	}
+	_, err = LoadConfig(path)
+	if err == nil || !strings.HasPrefix(err.Error(), path+":2: ") {
+		t1.Errorf("LoadConfig() error = %v, want it to point at %s:2", err, path)
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "topology.toml")); err == nil {
+		t1.Error("LoadConfig() should reject unknown extensions")
+	}
+}
+
+func TestConfig_Marshal(t1 *testing.T) {
+	c := Config{
+		Queues: []QueueSpec{
+			{Name: "mail", PollingInterval: 200, MaxWorkers: 10, MaxDispatchesPerSecond: 5, MaxBurstSize: 10},
+			{Name: "default", PollingInterval: 100, MaxWorkers: 20},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "mail.send", QueueName: "mail"},
+			{JobCategory: "mail.bounce", QueueName: "mail"},
+		},
+	}
+
+	got, err := c.Marshal(ConfigYAML)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	want := `queues:
+  - name: default
+    polling_interval: 100
+    max_workers: 20
+  - name: mail
+    polling_interval: 200
+    max_workers: 10
+    max_dispatches_per_second: 5
+    max_burst_size: 10
+routings:
+  - job_category: mail.bounce
+    queue_name: mail
+  - job_category: mail.send
+    queue_name: mail
+`
+	if string(got) != want {
+		t1.Errorf("Marshal(ConfigYAML) = %s, want %s", got, want)
+	}
+
+	for _, format := range []ConfigFormat{ConfigYAML, ConfigJSON} {
+		data, err := c.Marshal(format)
+		if err != nil {
+			t1.Fatal(err)
+		}
+		parsed, err := ParseConfig(data, format)
+		if err != nil {
+			t1.Fatalf("ParseConfig(%s) error = %v", format, err)
+		}
+		again, err := parsed.Marshal(format)
+		if err != nil {
+			t1.Fatal(err)
+		}
+		if string(again) != string(data) {
+			t1.Errorf("%s output is not stable: %s != %s", format, again, data)
+		}
+	}
+}
+
+func TestExportConfig(t1 *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queues":
+			fmt.Fprint(w, `[{"name":"mail","polling_interval":200,"max_workers":10,"max_dispatches_per_second":5,"max_burst_size":10},{"name":"default","polling_interval":100,"max_workers":20}]`)
+		case "/routings":
+			fmt.Fprint(w, `[{"job_category":"mail.send","queue_name":"mail"},{"job_category":"batch","queue_name":"default"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	got, err := ExportConfig(context.Background(), NewTsutsu(server.URL))
+	if err != nil {
+		t1.Fatal(err)
+	}
+	want := Config{
+		Queues: []QueueSpec{
+			{Name: "default", PollingInterval: 100, MaxWorkers: 20},
+			{Name: "mail", PollingInterval: 200, MaxWorkers: 10, MaxDispatchesPerSecond: 5, MaxBurstSize: 10},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "batch", QueueName: "default"},
+			{JobCategory: "mail.send", QueueName: "mail"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t1.Errorf("ExportConfig() = %v, want %v", got, want)
+	}
+}
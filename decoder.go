@@ -1,13 +1,16 @@
 package tsutsu
 
 import (
+	"context"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 )
 
 type httpBodyDecoder struct {
 	body    io.ReadCloser
 	decoder *json.Decoder
+	cancel  context.CancelFunc
 }
 
 func newHttpBodyDecoder(body io.ReadCloser) *httpBodyDecoder {
@@ -19,9 +22,17 @@ func newHttpBodyDecoder(body io.ReadCloser) *httpBodyDecoder {
 }
 
 func (h *httpBodyDecoder) Close() error {
+	if h.cancel != nil {
+		defer h.cancel()
+	}
 	return h.body.Close()
 }
 
 func (h *httpBodyDecoder) Decode(v interface{}) error {
 	return h.decoder.Decode(v)
 }
+
+func (h *httpBodyDecoder) ReadString() (string, error) {
+	buf, err := ioutil.ReadAll(h.body)
+	return string(buf), err
+}
@@ -0,0 +1,189 @@
+package tsutsu
+
+import (
+	"context"
+	"fmt"
+	"github.com/fireworq/fireworq/model"
+	"strings"
+)
+
+// QueueDiff is a queue that is missing on one side or whose settings differ.
+// Left or Right is nil when the queue exists only on the other side.
+type QueueDiff struct {
+	Name  string     `json:"name"`
+	Left  *QueueSpec `json:"left"`
+	Right *QueueSpec `json:"right"`
+}
+
+// RoutingDiff is a routing that is missing on one side or points at
+// different queues. LeftQueue or RightQueue is empty when the routing exists
+// only on the other side.
+type RoutingDiff struct {
+	JobCategory string `json:"job_category"`
+	LeftQueue   string `json:"left_queue,omitempty"`
+	RightQueue  string `json:"right_queue,omitempty"`
+}
+
+// TopologyDiff is the difference between two topologies, sorted by name.
+// It is rendered as text by String and as JSON by encoding/json.
+type TopologyDiff struct {
+	Left     string        `json:"left"`
+	Right    string        `json:"right"`
+	Queues   []QueueDiff   `json:"queues"`
+	Routings []RoutingDiff `json:"routings"`
+}
+
+func (d TopologyDiff) Empty() bool {
+	return len(d.Queues) == 0 && len(d.Routings) == 0
+}
+
+// String renders the diff with "<" for the left side only, ">" for the right
+// side only and "~" for differences.
+func (d TopologyDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "< %s\n> %s\n", d.Left, d.Right)
+	if d.Empty() {
+		b.WriteString("no differences\n")
+		return b.String()
+	}
+	for _, q := range d.Queues {
+		switch {
+		case q.Right == nil:
+			fmt.Fprintf(&b, "< queue %s (%s)\n", q.Name, strings.Join(queueFields(*q.Left), ", "))
+		case q.Left == nil:
+			fmt.Fprintf(&b, "> queue %s (%s)\n", q.Name, strings.Join(queueFields(*q.Right), ", "))
+		default:
+			fmt.Fprintf(&b, "~ queue %s (%s)\n", q.Name, strings.Join(queueFieldChanges(*q.Left, *q.Right), ", "))
+		}
+	}
+	for _, rt := range d.Routings {
+		switch {
+		case rt.RightQueue == "":
+			fmt.Fprintf(&b, "< routing %s -> %s\n", rt.JobCategory, rt.LeftQueue)
+		case rt.LeftQueue == "":
+			fmt.Fprintf(&b, "> routing %s -> %s\n", rt.JobCategory, rt.RightQueue)
+		default:
+			fmt.Fprintf(&b, "~ routing %s (queue_name: %s -> %s)\n", rt.JobCategory, rt.LeftQueue, rt.RightQueue)
+		}
+	}
+	return b.String()
+}
+
+// DiffTopology compares two topologies. The Left and Right labels of the
+// result are left empty for the caller to fill.
+func DiffTopology(left, right Topology) TopologyDiff {
+	d := TopologyDiff{Queues: []QueueDiff{}, Routings: []RoutingDiff{}}
+
+	leftQueues := map[string]QueueSpec{}
+	for _, q := range left.Queues {
+		leftQueues[q.Name] = q
+	}
+	rightQueues := map[string]QueueSpec{}
+	for _, q := range right.Queues {
+		rightQueues[q.Name] = q
+	}
+	seen := map[string]bool{}
+	for _, q := range sortedQueues(append(append([]QueueSpec(nil), left.Queues...), right.Queues...)) {
+		if seen[q.Name] {
+			continue
+		}
+		seen[q.Name] = true
+		l, inLeft := leftQueues[q.Name]
+		r, inRight := rightQueues[q.Name]
+		switch {
+		case inLeft && inRight && l == r:
+			continue
+		case !inLeft:
+			d.Queues = append(d.Queues, QueueDiff{Name: q.Name, Right: &r})
+		case !inRight:
+			d.Queues = append(d.Queues, QueueDiff{Name: q.Name, Left: &l})
+		default:
+			d.Queues = append(d.Queues, QueueDiff{Name: q.Name, Left: &l, Right: &r})
+		}
+	}
+
+	leftRoutings := map[string]string{}
+	for _, rt := range left.Routings {
+		leftRoutings[rt.JobCategory] = rt.QueueName
+	}
+	rightRoutings := map[string]string{}
+	for _, rt := range right.Routings {
+		rightRoutings[rt.JobCategory] = rt.QueueName
+	}
+	seen = map[string]bool{}
+	for _, rt := range sortedRoutings(append(append([]model.Routing(nil), left.Routings...), right.Routings...)) {
+		if seen[rt.JobCategory] {
+			continue
+		}
+		seen[rt.JobCategory] = true
+		if l, r := leftRoutings[rt.JobCategory], rightRoutings[rt.JobCategory]; l != r {
+			d.Routings = append(d.Routings, RoutingDiff{JobCategory: rt.JobCategory, LeftQueue: l, RightQueue: r})
+		}
+	}
+
+	return d
+}
+
+// Diff compares the topologies of two servers. Each side is labeled with
+// the base URL of its server when the client is a *Tsutsu.
+func Diff(ctx context.Context, left, right Client) (TopologyDiff, error) {
+	leftConfig, err := ExportConfig(ctx, left)
+	if err != nil {
+		return TopologyDiff{}, err
+	}
+	rightConfig, err := ExportConfig(ctx, right)
+	if err != nil {
+		return TopologyDiff{}, err
+	}
+
+	d := DiffTopology(leftConfig.Topology(), rightConfig.Topology())
+	d.Left, d.Right = clientLabel(left, "left"), clientLabel(right, "right")
+	return d, nil
+}
+
+// DiffConfig compares the topology of a server with a config the way a
+// Reconciler would apply it: the default queue of the server is left out
+// unless the config declares it, and a queue of the config that specifies no
+// throttling is compared with the throttling of the server.
+func DiffConfig(ctx context.Context, client Client, config Config) (TopologyDiff, error) {
+	settings, err := client.SettingsWithContext(ctx)
+	if err != nil {
+		return TopologyDiff{}, err
+	}
+	current, err := ExportConfig(ctx, client)
+	if err != nil {
+		return TopologyDiff{}, err
+	}
+
+	desired := config.Topology()
+	declared := map[string]bool{}
+	for _, q := range desired.Queues {
+		declared[q.Name] = true
+	}
+	currentQueues := map[string]QueueSpec{}
+	var kept []QueueSpec
+	for _, q := range current.Queues {
+		if q.Name == settings.QueueDefault && !declared[q.Name] {
+			continue
+		}
+		currentQueues[q.Name] = q
+		kept = append(kept, q)
+	}
+	current.Queues = kept
+	for i, q := range desired.Queues {
+		if c, ok := currentQueues[q.Name]; ok {
+			desired.Queues[i] = keepThrottling(q, c)
+		}
+	}
+
+	d := DiffTopology(current.Topology(), desired)
+	d.Left, d.Right = clientLabel(client, "server"), "config"
+	return d, nil
+}
+
+func clientLabel(client Client, fallback string) string {
+	if t, ok := client.(*Tsutsu); ok {
+		return t.BaseURL()
+	}
+	return fallback
+}
@@ -0,0 +1,138 @@
+package tsutsu_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"testing"
+)
+
+func TestDiff(t1 *testing.T) {
+	ctx := context.Background()
+	staging := tsutsutest.NewServer()
+	defer staging.Close()
+	production := tsutsutest.NewServer()
+	defer production.Close()
+
+	setup := func(s *tsutsutest.Server, queues []model.Queue, routings []model.Routing) {
+		t := s.Tsutsu()
+		for _, q := range queues {
+			if _, err := t.CreateQueue(q.Name, q.PollingInterval, q.MaxWorkers); err != nil {
+				t1.Fatal(err)
+			}
+		}
+		for _, rt := range routings {
+			if _, err := t.CreateRouting(rt.JobCategory, rt.QueueName); err != nil {
+				t1.Fatal(err)
+			}
+		}
+	}
+	setup(staging, []model.Queue{
+		{Name: "mail", PollingInterval: 100, MaxWorkers: 1},
+		{Name: "experimental", PollingInterval: 100, MaxWorkers: 1},
+	}, []model.Routing{
+		{JobCategory: "mail.send", QueueName: "mail"},
+		{JobCategory: "report", QueueName: "experimental"},
+	})
+	setup(production, []model.Queue{
+		{Name: "mail", PollingInterval: 100, MaxWorkers: 8},
+		{Name: "batch", PollingInterval: 500, MaxWorkers: 2},
+	}, []model.Routing{
+		{JobCategory: "mail.send", QueueName: "mail"},
+		{JobCategory: "report", QueueName: "batch"},
+	})
+
+	diff, err := tsutsu.Diff(ctx, staging.Tsutsu(), production.Tsutsu())
+	if err != nil {
+		t1.Fatal(err)
+	}
+
+	want := "< " + staging.URL + "\n" +
+		"> " + production.URL + "\n" +
+		"> queue batch (polling_interval: 500, max_workers: 2)\n" +
+		"< queue experimental (polling_interval: 100, max_workers: 1)\n" +
+		"~ queue mail (max_workers: 1 -> 8)\n" +
+		"~ routing report (queue_name: experimental -> batch)\n"
+	if got := diff.String(); got != want {
+		t1.Errorf("Diff() = %s, want %s", got, want)
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	var decoded tsutsu.TopologyDiff
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t1.Fatal(err)
+	}
+	if decoded.String() != want {
+		t1.Errorf("JSON round trip = %s, want %s", decoded.String(), want)
+	}
+
+	same, err := tsutsu.Diff(ctx, staging.Tsutsu(), staging.Tsutsu())
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if !same.Empty() {
+		t1.Errorf("Diff() of a server with itself = %s, want no differences", same)
+	}
+}
+
+func TestDiffConfig(t1 *testing.T) {
+	ctx := context.Background()
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	s.SetVersion("1.5.0")
+
+	if _, err := s.Tsutsu().PutQueue(tsutsu.QueueSpec{Name: "mail", PollingInterval: 100, MaxWorkers: 4, MaxDispatchesPerSecond: 2.5, MaxBurstSize: 5}); err != nil {
+		t1.Fatal(err)
+	}
+
+	config := tsutsu.Config{
+		Queues: []tsutsu.QueueSpec{
+			{Name: tsutsutest.DefaultQueueName, PollingInterval: 200, MaxWorkers: 20},
+			{Name: "mail", PollingInterval: 100, MaxWorkers: 4},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "mail.send", QueueName: tsutsutest.DefaultQueueName},
+		},
+	}
+	diff, err := tsutsu.DiffConfig(ctx, s.Tsutsu(), config)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if diff.Left != s.URL || diff.Right != "config" {
+		t1.Errorf("labels = %q, %q", diff.Left, diff.Right)
+	}
+	if len(diff.Queues) != 0 {
+		t1.Errorf("Queues = %v, want mail to keep the throttling of the server", diff.Queues)
+	}
+	if len(diff.Routings) != 1 || diff.Routings[0].RightQueue != tsutsutest.DefaultQueueName {
+		t1.Errorf("Routings = %v, want mail.send only in the config", diff.Routings)
+	}
+}
+
+func TestDiffConfig_defaultQueue(t1 *testing.T) {
+	ctx := context.Background()
+	s := tsutsutest.NewServer()
+	defer s.Close()
+
+	if _, err := s.Tsutsu().CreateQueue("mail", 100, 4); err != nil {
+		t1.Fatal(err)
+	}
+
+	config := tsutsu.Config{
+		Queues: []tsutsu.QueueSpec{
+			{Name: "mail", PollingInterval: 100, MaxWorkers: 4},
+		},
+	}
+	diff, err := tsutsu.DiffConfig(ctx, s.Tsutsu(), config)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if !diff.Empty() {
+		t1.Errorf("DiffConfig() got =\n%s\nwant the undeclared default queue left out", diff)
+	}
+}
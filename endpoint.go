@@ -0,0 +1,98 @@
+package tsutsu
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fireworq stores names in VARCHAR(255) columns.
+const maxNameLength = 255
+
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("tsutsu: invalid base url %q: %v", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("tsutsu: invalid base url %q: scheme must be http or https", baseURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("tsutsu: invalid base url %q: missing host", baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("tsutsu: invalid base url %q: query and fragment are not allowed", baseURL)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// endpoint joins elem onto the base URL path, keeping any path prefix such
+// as a reverse proxy mount point. Each element is escaped as a single path
+// segment.
+func (t *Tsutsu) endpoint(elem ...string) *url.URL {
+	var u url.URL
+	if t.baseURL != nil {
+		u = *t.baseURL
+	}
+
+	escaped := make([]string, len(elem))
+	for i, e := range elem {
+		escaped[i] = url.PathEscape(e)
+	}
+
+	rawPath := u.EscapedPath()
+	u.Path = u.Path + "/" + strings.Join(elem, "/")
+	u.RawPath = rawPath + "/" + strings.Join(escaped, "/")
+	return &u
+}
+
+func validateName(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: %s must not be empty", ErrInvalidName, kind)
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w: %s %q is longer than %d bytes", ErrInvalidName, kind, name, maxNameLength)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("%w: %s %q is not valid UTF-8", ErrInvalidName, kind, name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: %s %q contains a control character", ErrInvalidName, kind, name)
+		}
+	}
+	return nil
+}
+
+// validateQueueName follows the /queue/{queue:[^/]+} route of Fireworq.
+func validateQueueName(name string) error {
+	if err := validateName("queue name", name); err != nil {
+		return err
+	}
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("%w: queue name %q must not contain '/'", ErrInvalidName, name)
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("%w: queue name %q is not allowed", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// validateJobCategory follows the /routing/{category:.+} route of Fireworq.
+// Categories may contain '/', but not segments the server would clean away.
+func validateJobCategory(category string) error {
+	if err := validateName("job category", category); err != nil {
+		return err
+	}
+	for _, segment := range strings.Split(category, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: job category %q must not contain empty, '.' or '..' path segments", ErrInvalidName, category)
+		}
+	}
+	return nil
+}
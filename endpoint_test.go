@@ -0,0 +1,120 @@
+package tsutsu
+
+import (
+	"errors"
+	"github.com/fireworq/fireworq/model"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew_baseURL(t1 *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", baseURL: "http://localhost:8080", want: "http://localhost:8080/queues", wantErr: false},
+		{name: "trailing slash", baseURL: "http://localhost:8080/", want: "http://localhost:8080/queues", wantErr: false},
+		{name: "path prefix", baseURL: "https://example.com/fireworq/", want: "https://example.com/fireworq/queues", wantErr: false},
+		{name: "missing scheme", baseURL: "localhost:8080", wantErr: true},
+		{name: "missing host", baseURL: "http://", wantErr: true},
+		{name: "query", baseURL: "http://localhost:8080/?a=b", wantErr: true},
+		{name: "broken", baseURL: "http://local host:8080", wantErr: true},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			t, err := New(tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if _, err := NewTsutsu(tt.baseURL).Queues(); err == nil {
+					t1.Error("Queues() error = nil, want error")
+				}
+				return
+			}
+			if got := t.endpoint("queues").String(); got != tt.want {
+				t1.Errorf("endpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTsutsu_pathPrefix(t1 *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RequestURI()
+		w.Write([]byte(`{"jobs":[],"next_cursor":""}`))
+	}))
+	defer server.Close()
+
+	t := NewTsutsu(server.URL + "/fireworq/")
+	if _, err := t.Job().Limit(10).Asc().Waiting("default"); err != nil {
+		t1.Fatal(err)
+	}
+	if want := "/fireworq/queue/default/waiting?limit=10&order=asc"; got != want {
+		t1.Errorf("request uri = %v, want %v", got, want)
+	}
+}
+
+func TestTsutsu_escapedCategory(t1 *testing.T) {
+	category := "test category/with?query#fragment%"
+	t := NewTsutsu(FIREWORQ_URL)
+	defer t.DeleteRouting(category)
+
+	tests := []struct {
+		name string
+		call func() (model.Routing, error)
+	}{
+		{name: "create", call: func() (model.Routing, error) { return t.CreateRouting(category, "default") }},
+		{name: "get", call: func() (model.Routing, error) { return t.Routing(category) }},
+		{name: "delete", call: func() (model.Routing, error) { return t.DeleteRouting(category) }},
+	}
+	want := model.Routing{QueueName: "default", JobCategory: category}
+	for _, tt := range tests {
+		got, err := tt.call()
+		if err != nil {
+			t1.Fatalf("%s: error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t1.Errorf("%s: got = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestValidateNames(t1 *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		value    string
+		wantErr  bool
+	}{
+		{name: "queue", validate: validateQueueName, value: "my_queue-1", wantErr: false},
+		{name: "queue with space", validate: validateQueueName, value: "my queue", wantErr: false},
+		{name: "empty queue", validate: validateQueueName, value: "", wantErr: true},
+		{name: "queue with slash", validate: validateQueueName, value: "a/b", wantErr: true},
+		{name: "dot queue", validate: validateQueueName, value: "..", wantErr: true},
+		{name: "long queue", validate: validateQueueName, value: strings.Repeat("q", 256), wantErr: true},
+		{name: "queue with newline", validate: validateQueueName, value: "a\nb", wantErr: true},
+		{name: "category with slash", validate: validateJobCategory, value: "mail/send", wantErr: false},
+		{name: "category with empty segment", validate: validateJobCategory, value: "mail//send", wantErr: true},
+		{name: "category with dot segment", validate: validateJobCategory, value: "mail/../send", wantErr: true},
+		{name: "category with trailing slash", validate: validateJobCategory, value: "mail/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			err := tt.validate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidName) {
+				t1.Errorf("error = %v, want ErrInvalidName", err)
+			}
+		})
+	}
+}
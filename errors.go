@@ -0,0 +1,75 @@
+package tsutsu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const maxErrorBodySize = 4096
+
+var (
+	ErrBadRequest     = errors.New("tsutsu: bad request")
+	ErrNotFound       = errors.New("tsutsu: not found")
+	ErrConflict       = errors.New("tsutsu: conflict")
+	ErrNotImplemented = errors.New("tsutsu: not implemented")
+	ErrServerError    = errors.New("tsutsu: server error")
+	ErrInvalidName    = errors.New("tsutsu: invalid name")
+)
+
+// APIError is returned for every non-200 response from Fireworq.
+// It matches the sentinel errors above with errors.Is.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Message    string
+}
+
+func newAPIError(req *http.Request, res *http.Response) *APIError {
+	buf, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	return &APIError{
+		StatusCode: res.StatusCode,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Message:    errorMessage(res.StatusCode, string(buf)),
+	}
+}
+
+// errorMessage drops the "404 Not Found" status line Fireworq puts in front
+// of its error details.
+func errorMessage(statusCode int, body string) string {
+	body = strings.TrimSpace(body)
+	statusLine := fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode))
+	if strings.HasPrefix(body, statusLine) {
+		body = strings.TrimSpace(strings.TrimPrefix(body, statusLine))
+	}
+	return body
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("tsutsu: %s %s: status_code: %d", e.Method, e.URL, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrNotImplemented:
+		return e.StatusCode == http.StatusNotImplemented
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
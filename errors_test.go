@@ -0,0 +1,56 @@
+package tsutsu
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIError(t1 *testing.T) {
+	tests := []struct {
+		name        string
+		call        func(t *Tsutsu) error
+		wantTarget  error
+		wantStatus  int
+		wantMethod  string
+		wantMessage string
+	}{
+		{
+			name: "missing queue should be not found",
+			call: func(t *Tsutsu) error {
+				_, err := t.Queue("not_found")
+				return err
+			},
+			wantTarget:  ErrNotFound,
+			wantStatus:  http.StatusNotFound,
+			wantMethod:  http.MethodGet,
+			wantMessage: "",
+		},
+		{
+			name: "job without url should be bad request",
+			call: func(t *Tsutsu) error {
+				_, err := t.PushJob("test_category", JobRequest{})
+				return err
+			},
+			wantTarget:  ErrBadRequest,
+			wantStatus:  http.StatusBadRequest,
+			wantMethod:  http.MethodPost,
+			wantMessage: "Missing field: url",
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			err := tt.call(NewTsutsu(FIREWORQ_URL))
+			if !errors.Is(err, tt.wantTarget) {
+				t1.Fatalf("error = %v, want %v", err, tt.wantTarget)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t1.Fatalf("error = %T, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Method != tt.wantMethod || apiErr.Message != tt.wantMessage {
+				t1.Errorf("APIError = %+v, want status %d, method %s, message %q", apiErr, tt.wantStatus, tt.wantMethod, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Package collector registers the metrics of package exporter with a
+// prometheus.Registry, for services that already serve one:
+//
+//	prometheus.MustRegister(collector.New(client))
+package collector
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/exporter"
+	"time"
+)
+
+// Collector is a prometheus.Collector scraping the server on every Collect
+// and emitting the families of an exporter.Exporter as const metrics.
+type Collector struct {
+	exporter *exporter.Exporter
+	descs    map[string]*prometheus.Desc
+	families []exporter.Family
+}
+
+func New(client tsutsu.Client) *Collector {
+	c := &Collector{
+		exporter: exporter.New(client),
+		descs:    map[string]*prometheus.Desc{},
+		families: exporter.Families(),
+	}
+	for _, f := range c.families {
+		var labels []string
+		if f.ByQueue {
+			labels = []string{"queue"}
+		}
+		c.descs[f.Name] = prometheus.NewDesc(f.Name, f.Help, labels, nil)
+	}
+	return c
+}
+
+// Timeout bounds each scrape of the server. The default is 10 seconds.
+func (c *Collector) Timeout(timeout time.Duration) *Collector {
+	c.exporter.Timeout(timeout)
+	return c
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, f := range c.families {
+		ch <- c.descs[f.Name]
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, f := range c.exporter.Gather(context.Background()) {
+		valueType := prometheus.GaugeValue
+		if f.Type == "counter" {
+			valueType = prometheus.CounterValue
+		}
+		for _, s := range f.Samples {
+			var labels []string
+			if f.ByQueue {
+				labels = []string{s.Queue}
+			}
+			ch <- prometheus.MustNewConstMetric(c.descs[f.Name], valueType, s.Value, labels...)
+		}
+	}
+}
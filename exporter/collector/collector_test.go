@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCollector(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	client := s.Tsutsu()
+
+	if _, err := client.CreateQueue("mail", 500, 4); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := client.CreateRouting("mail.send", "mail"); err != nil {
+		t1.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.PushJob("mail.send", tsutsu.JobRequest{URL: "http://localhost/"}); err != nil {
+			t1.Fatal(err)
+		}
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(New(client)); err != nil {
+		t1.Fatal(err)
+	}
+
+	want := `# HELP fireworq_up Whether the last scrape of Fireworq succeeded.
+# TYPE fireworq_up gauge
+fireworq_up 1
+# HELP fireworq_queue_pushes_total Total number of jobs pushed to the queue.
+# TYPE fireworq_queue_pushes_total counter
+fireworq_queue_pushes_total{queue="default"} 0
+fireworq_queue_pushes_total{queue="mail"} 3
+# HELP fireworq_queue_polling_interval_seconds Configured polling interval of the queue.
+# TYPE fireworq_queue_polling_interval_seconds gauge
+fireworq_queue_polling_interval_seconds{queue="default"} 0.2
+fireworq_queue_polling_interval_seconds{queue="mail"} 0.5
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "fireworq_up", "fireworq_queue_pushes_total", "fireworq_queue_polling_interval_seconds"); err != nil {
+		t1.Error(err)
+	}
+}
+
+func TestCollector_down(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	s.InjectError(http.MethodGet, "/queues", http.StatusInternalServerError, "")
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(New(s.Tsutsu()))
+
+	want := `# HELP fireworq_up Whether the last scrape of Fireworq succeeded.
+# TYPE fireworq_up gauge
+fireworq_up 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "fireworq_up", "fireworq_queue_pushes_total"); err != nil {
+		t1.Error(err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package exporter exposes the stats of Fireworq queues to Prometheus.
+//
+// An Exporter scrapes the queues of a server and their stats on every
+// request and writes them in the Prometheus text exposition format:
+//
+//	http.Handle("/metrics", exporter.New(client))
+//
+// Package collector registers the same metrics with a prometheus.Registry.
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/internal/exposition"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const namespace = "fireworq"
+
+// Family is a metric family of an Exporter, with the samples of a scrape when
+// returned by Gather.
+type Family struct {
+	Name string
+	Help string
+	Type string
+	// ByQueue families have one sample per queue, labeled by queue.
+	ByQueue bool
+	Samples []Sample
+}
+
+// Sample is a value of a Family. Queue is empty unless the family is ByQueue.
+type Sample struct {
+	Queue string
+	Value float64
+}
+
+var (
+	upFamily             = Family{Name: namespace + "_up", Help: "Whether the last scrape of Fireworq succeeded.", Type: "gauge"}
+	scrapeDurationFamily = Family{Name: namespace + "_scrape_duration_seconds", Help: "Time the scrape of Fireworq took.", Type: "gauge"}
+)
+
+// metric is a metric family with one sample per queue.
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func(q model.Queue, s tsutsu.QueueStats) float64
+}
+
+var queueMetrics = []metric{
+	{"queue_pushes_total", "Total number of jobs pushed to the queue.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalPushes) }},
+	{"queue_pops_total", "Total number of jobs popped from the queue.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalPops) }},
+	{"queue_successes_total", "Total number of jobs that succeeded.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalSuccesses) }},
+	{"queue_failures_total", "Total number of failed job attempts.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalFailures) }},
+	{"queue_permanent_failures_total", "Total number of jobs that failed without retries left.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalPermanentFailures) }},
+	{"queue_completes_total", "Total number of jobs that completed by success or permanent failure.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalCompletes) }},
+	{"queue_elapsed_seconds_total", "Total time from push to completion of completed jobs.", "counter", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalElapsed) / 1000 }},
+	{"queue_pushes_per_second", "Number of jobs pushed in the last second.", "gauge", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.PushesPerSecond) }},
+	{"queue_pops_per_second", "Number of jobs popped in the last second.", "gauge", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.PopPerSecond) }},
+	{"queue_workers", "Number of workers of the queue.", "gauge", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.TotalWorkers) }},
+	{"queue_idle_workers", "Number of idle workers of the queue.", "gauge", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.IdleWorkers) }},
+	{"queue_active_nodes", "Number of nodes dispatching jobs of the queue.", "gauge", func(_ model.Queue, s tsutsu.QueueStats) float64 { return float64(s.ActiveNodes) }},
+	{"queue_max_workers", "Configured maximum number of workers of the queue.", "gauge", func(q model.Queue, _ tsutsu.QueueStats) float64 { return float64(q.MaxWorkers) }},
+	{"queue_polling_interval_seconds", "Configured polling interval of the queue.", "gauge", func(q model.Queue, _ tsutsu.QueueStats) float64 { return float64(q.PollingInterval) / 1000 }},
+}
+
+type Exporter struct {
+	client  tsutsu.Client
+	timeout time.Duration
+}
+
+func New(client tsutsu.Client) *Exporter {
+	return &Exporter{client: client, timeout: 10 * time.Second}
+}
+
+// Timeout bounds each scrape of the server. The default is 10 seconds.
+func (e *Exporter) Timeout(timeout time.Duration) *Exporter {
+	e.timeout = timeout
+	return e
+}
+
+func (m metric) family() Family {
+	return Family{Name: namespace + "_" + m.name, Help: m.help, Type: m.kind, ByQueue: true}
+}
+
+// Families returns the families an Exporter may write, without samples.
+func Families() []Family {
+	families := []Family{upFamily, scrapeDurationFamily}
+	for _, m := range queueMetrics {
+		families = append(families, m.family())
+	}
+	return families
+}
+
+type queueStats struct {
+	queue model.Queue
+	stats tsutsu.QueueStats
+}
+
+func (e *Exporter) scrape(ctx context.Context) ([]queueStats, error) {
+	queues, err := e.client.QueuesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i].Name < queues[j].Name })
+
+	var scraped []queueStats
+	for _, q := range queues {
+		stats, err := e.client.StatsWithContext(ctx, q.Name)
+		if errors.Is(err, tsutsu.ErrNotFound) {
+			// Deleted after it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scraped = append(scraped, queueStats{queue: q, stats: stats})
+	}
+	return scraped, nil
+}
+
+// Gather scrapes the server and returns the families with their samples. A
+// failed scrape is reported by fireworq_up 0 and no queue families rather
+// than as an error.
+func (e *Exporter) Gather(ctx context.Context) []Family {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	scraped, err := e.scrape(ctx)
+	duration := time.Since(start)
+
+	up, scrapeDuration := upFamily, scrapeDurationFamily
+	up.Samples = []Sample{{Value: 1}}
+	if err != nil {
+		up.Samples = []Sample{{Value: 0}}
+		scraped = nil
+	}
+	scrapeDuration.Samples = []Sample{{Value: duration.Seconds()}}
+	families := []Family{up, scrapeDuration}
+
+	if len(scraped) > 0 {
+		for _, m := range queueMetrics {
+			f := m.family()
+			for _, qs := range scraped {
+				f.Samples = append(f.Samples, Sample{Queue: qs.queue.Name, Value: m.value(qs.queue, qs.stats)})
+			}
+			families = append(families, f)
+		}
+	}
+	return families
+}
+
+// Collect writes the families returned by Gather to w. The error returned is
+// only that of writing to w.
+func (e *Exporter) Collect(ctx context.Context, w io.Writer) error {
+	var buf bytes.Buffer
+	for _, f := range e.Gather(ctx) {
+		exposition.WriteFamily(&buf, f.Name, f.Help, f.Type)
+		for _, sample := range f.Samples {
+			if f.ByQueue {
+				exposition.WriteSample(&buf, f.Name, sample.Value, exposition.Label{Name: "queue", Value: sample.Queue})
+			} else {
+				exposition.WriteSample(&buf, f.Name, sample.Value)
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", exposition.ContentType)
+	e.Collect(r.Context(), w)
+}
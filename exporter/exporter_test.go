@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/internal/exposition"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExporter(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	client := s.Tsutsu()
+
+	if _, err := client.CreateQueue(`say "hi"`, 500, 4); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := client.CreateRouting("greeting", `say "hi"`); err != nil {
+		t1.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.PushJob("greeting", tsutsu.JobRequest{URL: "http://localhost/"}); err != nil {
+			t1.Fatal(err)
+		}
+	}
+	if err := s.Grab(`say "hi"`, 1); err != nil {
+		t1.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	New(client).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := recorder.Header().Get("Content-Type"); got != exposition.ContentType {
+		t1.Errorf("Content-Type = %q", got)
+	}
+
+	body, _ := ioutil.ReadAll(recorder.Body)
+	for _, want := range []string{
+		"# TYPE fireworq_up gauge\nfireworq_up 1\n",
+		"# HELP fireworq_queue_pushes_total Total number of jobs pushed to the queue.\n# TYPE fireworq_queue_pushes_total counter\nfireworq_queue_pushes_total{queue=\"default\"} 0\nfireworq_queue_pushes_total{queue=\"say \\\"hi\\\"\"} 3\n",
+		"fireworq_queue_idle_workers{queue=\"say \\\"hi\\\"\"} 3\n",
+		"fireworq_queue_polling_interval_seconds{queue=\"say \\\"hi\\\"\"} 0.5\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t1.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestExporter_down(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	s.InjectError(http.MethodGet, "/queue/*/stats", http.StatusInternalServerError, "")
+
+	var buf bytes.Buffer
+	if err := New(s.Tsutsu()).Collect(context.Background(), &buf); err != nil {
+		t1.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "fireworq_up 0\n") || strings.Contains(buf.String(), "fireworq_queue_") {
+		t1.Errorf("metrics of a failed scrape = %s", buf.String())
+	}
+}
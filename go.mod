@@ -2,4 +2,8 @@ module github.com/stk132/tsutsu
 
 go 1.15
 
-require github.com/fireworq/fireworq v1.4.0
+require (
+	github.com/fireworq/fireworq v1.4.0
+	github.com/prometheus/client_golang v1.12.2
+	gopkg.in/yaml.v3 v3.0.1
+)
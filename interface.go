@@ -0,0 +1,81 @@
+package tsutsu
+
+import (
+	"context"
+	"github.com/fireworq/fireworq/model"
+)
+
+// Client is the API of *Tsutsu, for code that wants to swap in a mock such
+// as tsutsutest.MockClient.
+type Client interface {
+	Queues() ([]model.Queue, error)
+	QueuesWithContext(ctx context.Context) ([]model.Queue, error)
+	QueueSpecs() ([]QueueSpec, error)
+	QueueSpecsWithContext(ctx context.Context) ([]QueueSpec, error)
+	Queue(name string) (model.Queue, error)
+	QueueWithContext(ctx context.Context, name string) (model.Queue, error)
+	Stats(queueName string) (QueueStats, error)
+	StatsWithContext(ctx context.Context, queueName string) (QueueStats, error)
+	AllStats() (map[string]QueueStats, error)
+	AllStatsWithContext(ctx context.Context) (map[string]QueueStats, error)
+	Node(queueName string) (NodeInfo, error)
+	NodeWithContext(ctx context.Context, queueName string) (NodeInfo, error)
+	CreateQueue(name string, pollingInterval, maxWorkers uint) (model.Queue, error)
+	CreateQueueWithContext(ctx context.Context, name string, pollingInterval, maxWorkers uint) (model.Queue, error)
+	PutQueue(spec QueueSpec) (QueueSpec, error)
+	PutQueueWithContext(ctx context.Context, spec QueueSpec) (QueueSpec, error)
+	DeleteQueue(name string) (model.Queue, error)
+	DeleteQueueWithContext(ctx context.Context, name string) (model.Queue, error)
+	Routings() ([]model.Routing, error)
+	RoutingsWithContext(ctx context.Context) ([]model.Routing, error)
+	Routing(jobCategory string) (model.Routing, error)
+	RoutingWithContext(ctx context.Context, jobCategory string) (model.Routing, error)
+	CreateRouting(jobCategory, queueName string) (model.Routing, error)
+	CreateRoutingWithContext(ctx context.Context, jobCategory, queueName string) (model.Routing, error)
+	DeleteRouting(jobCategory string) (model.Routing, error)
+	DeleteRoutingWithContext(ctx context.Context, jobCategory string) (model.Routing, error)
+	PushJob(category string, job JobRequest) (PushResult, error)
+	PushJobWithContext(ctx context.Context, category string, job JobRequest) (PushResult, error)
+	Jobs() JobInspection
+	Version() (ServerVersion, error)
+	VersionWithContext(ctx context.Context) (ServerVersion, error)
+	Settings() (Settings, error)
+	SettingsWithContext(ctx context.Context) (Settings, error)
+}
+
+// JobInspection is the API of *JobInspector, as returned by Jobs.
+type JobInspection interface {
+	Limit(limit uint) JobInspection
+	Asc() JobInspection
+	Desc() JobInspection
+	Cursor(cursor string) JobInspection
+	Grabbed(queueName string) (JobsInfo, error)
+	GrabbedWithContext(ctx context.Context, queueName string) (JobsInfo, error)
+	Waiting(queueName string) (JobsInfo, error)
+	WaitingWithContext(ctx context.Context, queueName string) (JobsInfo, error)
+	Deferred(queueName string) (JobsInfo, error)
+	DeferredWithContext(ctx context.Context, queueName string) (JobsInfo, error)
+	Failed(queueName string) (FailedJobsInfo, error)
+	FailedWithContext(ctx context.Context, queueName string) (FailedJobsInfo, error)
+	Get(queueName string, id uint64) (JobInfo, error)
+	GetWithContext(ctx context.Context, queueName string, id uint64) (JobInfo, error)
+	Delete(queueName string, id uint64) (JobInfo, error)
+	DeleteWithContext(ctx context.Context, queueName string, id uint64) (JobInfo, error)
+	GetFailed(queueName string, id uint64) (FailedJobInfo, error)
+	GetFailedWithContext(ctx context.Context, queueName string, id uint64) (FailedJobInfo, error)
+	DeleteFailed(queueName string, id uint64) (FailedJobInfo, error)
+	DeleteFailedWithContext(ctx context.Context, queueName string, id uint64) (FailedJobInfo, error)
+	GrabbedAll(queueName string, fn func(JobInfo) error) error
+	GrabbedAllWithContext(ctx context.Context, queueName string, fn func(JobInfo) error) error
+	WaitingAll(queueName string, fn func(JobInfo) error) error
+	WaitingAllWithContext(ctx context.Context, queueName string, fn func(JobInfo) error) error
+	DeferredAll(queueName string, fn func(JobInfo) error) error
+	DeferredAllWithContext(ctx context.Context, queueName string, fn func(JobInfo) error) error
+	FailedAll(queueName string, fn func(FailedJobInfo) error) error
+	FailedAllWithContext(ctx context.Context, queueName string, fn func(FailedJobInfo) error) error
+}
+
+var (
+	_ Client        = (*Tsutsu)(nil)
+	_ JobInspection = jobInspection{}
+)
@@ -0,0 +1,50 @@
+// Package exposition writes metrics in the Prometheus text exposition format,
+// for the metrics of tsutsu and of its exporter, without depending on the
+// Prometheus client library.
+package exposition
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// WriteFamily writes the HELP and TYPE lines that precede the samples of a
+// metric family.
+func WriteFamily(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// WriteSample writes one sample with the labels in the given order.
+func WriteSample(w io.Writer, name string, value float64, labels ...Label) {
+	var b strings.Builder
+	b.WriteString(name)
+	for i, l := range labels {
+		if i == 0 {
+			b.WriteString("{")
+		} else {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `%s="%s"`, l.Name, labelEscaper.Replace(l.Value))
+	}
+	if len(labels) > 0 {
+		b.WriteString("}")
+	}
+	fmt.Fprintf(w, "%s %s\n", b.String(), FormatValue(value))
+}
+
+// FormatValue formats a sample value or a bucket bound.
+func FormatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
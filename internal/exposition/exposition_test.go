@@ -0,0 +1,40 @@
+package exposition
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestWriteFamily(t1 *testing.T) {
+	var b bytes.Buffer
+	WriteFamily(&b, "fireworq_up", "Whether the last scrape of Fireworq succeeded.", "gauge")
+	want := "# HELP fireworq_up Whether the last scrape of Fireworq succeeded.\n# TYPE fireworq_up gauge\n"
+	if b.String() != want {
+		t1.Errorf("WriteFamily() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteSample(t1 *testing.T) {
+	tests := []struct {
+		name   string
+		value  float64
+		labels []Label
+		want   string
+	}{
+		{name: "no labels", value: 1, want: "m 1\n"},
+		{name: "fraction", value: 0.25, labels: []Label{{"queue", "mail"}}, want: "m{queue=\"mail\"} 0.25\n"},
+		{name: "escaped", value: 2, labels: []Label{{"queue", "a\\b\"c\nd"}}, want: "m{queue=\"a\\\\b\\\"c\\nd\"} 2\n"},
+		{name: "several labels", value: 3, labels: []Label{{"operation", "Queues"}, {"code", "200"}, {"le", "+Inf"}}, want: "m{operation=\"Queues\",code=\"200\",le=\"+Inf\"} 3\n"},
+		{name: "infinity", value: math.Inf(1), want: "m +Inf\n"},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			var b bytes.Buffer
+			WriteSample(&b, "m", tt.value, tt.labels...)
+			if b.String() != tt.want {
+				t1.Errorf("WriteSample() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package tsutsu
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stk132/tsutsu/internal/exposition"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsRecorder observes every call to Fireworq, retries included, once
+// its response headers arrive. operation is the Tsutsu method name as
+// returned by Operation, and statusCode is 0 when no response was received.
+// err is the error the call returns, an *APIError for non-200 responses.
+type MetricsRecorder interface {
+	ObserveRequest(operation string, statusCode int, duration time.Duration, err error)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, int, time.Duration, error) {}
+
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(t *Tsutsu) error {
+		if recorder == nil {
+			return errors.New("tsutsu: metrics recorder must not be nil")
+		}
+		t.metrics = recorder
+		return nil
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency
+// histogram of PrometheusMetrics, the same as those of the Prometheus client.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type requestLabels struct {
+	operation  string
+	statusCode int
+}
+
+type latencyHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// PrometheusMetrics is a MetricsRecorder keeping the following metrics,
+// labeled by operation and code, in memory. WriteTo writes them in the
+// Prometheus text format, and ServeHTTP serves them to a scraper:
+//
+//	tsutsu_requests_total
+//	tsutsu_request_errors_total
+//	tsutsu_request_duration_seconds (histogram)
+type PrometheusMetrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	errors    map[requestLabels]uint64
+	latencies map[requestLabels]*latencyHistogram
+}
+
+// NewPrometheusMetrics uses DefaultLatencyBuckets without buckets.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusMetrics{
+		buckets:   buckets,
+		requests:  map[requestLabels]uint64{},
+		errors:    map[requestLabels]uint64{},
+		latencies: map[requestLabels]*latencyHistogram{},
+	}
+}
+
+func (m *PrometheusMetrics) ObserveRequest(operation string, statusCode int, duration time.Duration, err error) {
+	labels := requestLabels{operation: operation, statusCode: statusCode}
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[labels]++
+	if err != nil {
+		m.errors[labels]++
+	}
+
+	h, ok := m.latencies[labels]
+	if !ok {
+		h = &latencyHistogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[labels] = h
+	}
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	m.mu.Lock()
+	labels := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].operation != labels[j].operation {
+			return labels[i].operation < labels[j].operation
+		}
+		return labels[i].statusCode < labels[j].statusCode
+	})
+
+	exposition.WriteFamily(&buf, "tsutsu_requests_total", "Total number of calls to Fireworq.", "counter")
+	for _, l := range labels {
+		exposition.WriteSample(&buf, "tsutsu_requests_total", float64(m.requests[l]), l.labels()...)
+	}
+	exposition.WriteFamily(&buf, "tsutsu_request_errors_total", "Total number of calls to Fireworq that failed.", "counter")
+	for _, l := range labels {
+		exposition.WriteSample(&buf, "tsutsu_request_errors_total", float64(m.errors[l]), l.labels()...)
+	}
+	exposition.WriteFamily(&buf, "tsutsu_request_duration_seconds", "Latency of calls to Fireworq.", "histogram")
+	for _, l := range labels {
+		h := m.latencies[l]
+		for i, bound := range m.buckets {
+			exposition.WriteSample(&buf, "tsutsu_request_duration_seconds_bucket", float64(h.counts[i]), append(l.labels(), exposition.Label{Name: "le", Value: exposition.FormatValue(bound)})...)
+		}
+		exposition.WriteSample(&buf, "tsutsu_request_duration_seconds_bucket", float64(h.count), append(l.labels(), exposition.Label{Name: "le", Value: "+Inf"})...)
+		exposition.WriteSample(&buf, "tsutsu_request_duration_seconds_sum", h.sum, l.labels()...)
+		exposition.WriteSample(&buf, "tsutsu_request_duration_seconds_count", float64(h.count), l.labels()...)
+	}
+	m.mu.Unlock()
+
+	return buf.WriteTo(w)
+}
+
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", exposition.ContentType)
+	m.WriteTo(w)
+}
+
+func (l requestLabels) labels() []exposition.Label {
+	return []exposition.Label{
+		{Name: "operation", Value: l.operation},
+		{Name: "code", Value: strconv.Itoa(l.statusCode)},
+	}
+}
@@ -0,0 +1,84 @@
+package tsutsu
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithMetrics(t1 *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queues":
+			w.Write([]byte(`[]`))
+		default:
+			http.Error(w, "404 Not Found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	metrics := NewPrometheusMetrics(0.5, 1)
+	t, err := New(server.URL, WithMetrics(metrics))
+	if err != nil {
+		t1.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := t.Queues(); err != nil {
+			t1.Fatal(err)
+		}
+	}
+	if _, err := t.Queue("missing"); !errors.Is(err, ErrNotFound) {
+		t1.Fatalf("Queue() error = %v, want ErrNotFound", err)
+	}
+
+	down, err := New("http://127.0.0.1:1", WithMetrics(metrics))
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := down.Routings(); err == nil {
+		t1.Fatal("Routings() should fail without a server")
+	}
+
+	var buf bytes.Buffer
+	if _, err := metrics.WriteTo(&buf); err != nil {
+		t1.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE tsutsu_requests_total counter\n" +
+			"tsutsu_requests_total{operation=\"Queue\",code=\"404\"} 1\n" +
+			"tsutsu_requests_total{operation=\"Queues\",code=\"200\"} 2\n" +
+			"tsutsu_requests_total{operation=\"Routings\",code=\"0\"} 1\n",
+		"tsutsu_request_errors_total{operation=\"Queue\",code=\"404\"} 1\n" +
+			"tsutsu_request_errors_total{operation=\"Queues\",code=\"200\"} 0\n" +
+			"tsutsu_request_errors_total{operation=\"Routings\",code=\"0\"} 1\n",
+		"tsutsu_request_duration_seconds_bucket{operation=\"Queues\",code=\"200\",le=\"0.5\"} 2\n" +
+			"tsutsu_request_duration_seconds_bucket{operation=\"Queues\",code=\"200\",le=\"1\"} 2\n" +
+			"tsutsu_request_duration_seconds_bucket{operation=\"Queues\",code=\"200\",le=\"+Inf\"} 2\n",
+		"tsutsu_request_duration_seconds_count{operation=\"Queues\",code=\"200\"} 2\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t1.Errorf("metrics do not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPrometheusMetrics_buckets(t1 *testing.T) {
+	metrics := NewPrometheusMetrics(1, 0.1)
+	metrics.ObserveRequest("Queues", http.StatusOK, 50*time.Millisecond, nil)
+	metrics.ObserveRequest("Queues", http.StatusOK, 500*time.Millisecond, nil)
+	metrics.ObserveRequest("Queues", http.StatusOK, 5*time.Second, nil)
+
+	var buf bytes.Buffer
+	metrics.WriteTo(&buf)
+	want := "tsutsu_request_duration_seconds_bucket{operation=\"Queues\",code=\"200\",le=\"0.1\"} 1\n" +
+		"tsutsu_request_duration_seconds_bucket{operation=\"Queues\",code=\"200\",le=\"1\"} 2\n" +
+		"tsutsu_request_duration_seconds_bucket{operation=\"Queues\",code=\"200\",le=\"+Inf\"} 3\n" +
+		"tsutsu_request_duration_seconds_sum{operation=\"Queues\",code=\"200\"} 5.55\n"
+	if !strings.Contains(buf.String(), want) {
+		t1.Errorf("histogram = %s, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,52 @@
+package tsutsu
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a whole call to Fireworq, retries included. The calling
+// method name is available from the request context through Operation.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+type operationKey struct{}
+
+// withOperation labels ctx with the public method being called. The
+// outermost label wins so that helpers calling other methods keep it.
+func withOperation(ctx context.Context, operation string) context.Context {
+	if Operation(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// Operation returns the Tsutsu method name (e.g. "CreateQueue") that issued
+// the request carrying ctx. JobInspector methods are named after the jobs
+// they inspect (e.g. "GetJob" for Get, "AllFailedJobs" for FailedAll).
+func Operation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(t *Tsutsu) error {
+		for _, middleware := range middlewares {
+			if middleware == nil {
+				return errors.New("tsutsu: middleware must not be nil")
+			}
+		}
+		t.middlewares = append(t.middlewares, middlewares...)
+		return nil
+	}
+}
+
+func (t *Tsutsu) roundTrip(req *http.Request) (*http.Response, error) {
+	next := RoundTripFunc(t.send)
+	for i := len(t.middlewares) - 1; i >= 0; i-- {
+		next = t.middlewares[i](next)
+	}
+	return next(req)
+}
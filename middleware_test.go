@@ -0,0 +1,85 @@
+package tsutsu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWithMiddleware(t1 *testing.T) {
+	var traceIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceIDs = append(traceIDs, r.Header.Get("X-Trace-Id"))
+		switch r.URL.Path {
+		case "/queues":
+			w.Write([]byte(`[]`))
+		default:
+			w.Write([]byte(`{"name":"test_queue","polling_interval":100,"max_workers":1}`))
+		}
+	}))
+	defer server.Close()
+
+	var calls []string
+	record := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			res, err := next(req)
+			calls = append(calls, Operation(req.Context())+" "+res.Status)
+			return res, err
+		}
+	}
+	trace := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Trace-Id", Operation(req.Context()))
+			return next(req)
+		}
+	}
+
+	t, err := New(server.URL, WithMiddleware(record, trace))
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := t.Queues(); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := t.CreateQueue("test_queue", 100, 1); err != nil {
+		t1.Fatal(err)
+	}
+
+	if want := []string{"Queues 200 OK", "CreateQueue 200 OK"}; !reflect.DeepEqual(calls, want) {
+		t1.Errorf("calls = %v, want %v", calls, want)
+	}
+	if want := []string{"Queues", "CreateQueue"}; !reflect.DeepEqual(traceIDs, want) {
+		t1.Errorf("trace ids = %v, want %v", traceIDs, want)
+	}
+}
+
+func TestOperation_jobInspection(t1 *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":1,"jobs":[],"failed_jobs":[],"next_cursor":""}`))
+	}))
+	defer server.Close()
+
+	var operations []string
+	record := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			operations = append(operations, Operation(req.Context()))
+			return next(req)
+		}
+	}
+	t, err := New(server.URL, WithMiddleware(record))
+	if err != nil {
+		t1.Fatal(err)
+	}
+
+	t.Job().Get("default", 1)
+	t.Job().Delete("default", 1)
+	t.Job().Failed("default")
+	t.Job().DeleteFailed("default", 1)
+	t.Job().WaitingAll("default", func(JobInfo) error { return nil })
+
+	want := []string{"GetJob", "DeleteJob", "FailedJobs", "DeleteFailedJob", "AllWaitingJobs"}
+	if !reflect.DeepEqual(operations, want) {
+		t1.Errorf("operations = %v, want %v", operations, want)
+	}
+}
@@ -2,9 +2,40 @@ package tsutsu
 
 import (
 	"encoding/json"
+	"github.com/fireworq/fireworq/model"
 	"time"
 )
 
+// QueueSpec is a full queue definition. model.Queue lacks the dispatch
+// throttling fields, which are left out of requests when zero.
+type QueueSpec struct {
+	Name                   string  `json:"name"`
+	PollingInterval        uint    `json:"polling_interval"`
+	MaxWorkers             uint    `json:"max_workers"`
+	MaxDispatchesPerSecond float64 `json:"max_dispatches_per_second,omitempty"`
+	MaxBurstSize           uint    `json:"max_burst_size,omitempty"`
+}
+
+func NewQueueSpec(queue model.Queue) QueueSpec {
+	return QueueSpec{
+		Name:            queue.Name,
+		PollingInterval: queue.PollingInterval,
+		MaxWorkers:      queue.MaxWorkers,
+	}
+}
+
+func (q QueueSpec) Queue() model.Queue {
+	return model.Queue{
+		Name:            q.Name,
+		PollingInterval: q.PollingInterval,
+		MaxWorkers:      q.MaxWorkers,
+	}
+}
+
+func (q QueueSpec) throttled() bool {
+	return q.MaxDispatchesPerSecond != 0 || q.MaxBurstSize != 0
+}
+
 type QueueStats struct {
 	TotalPushes            int64 `json:"total_pushes"`
 	TotalPops              int64 `json:"total_pops"`
@@ -14,7 +45,7 @@ type QueueStats struct {
 	TotalCompletes         int64 `json:"total_completes"`
 	TotalElapsed           int64 `json:"total_elapsed"`
 	PushesPerSecond        int64 `json:"pushes_per_second"`
-	PopPerSecond           int64 `json:"pop_per_second"`
+	PopPerSecond           int64 `json:"pops_per_second"`
 	TotalWorkers           int64 `json:"total_workers"`
 	IdleWorkers            int64 `json:"idle_workers"`
 	ActiveNodes            int64 `json:"active_nodes"`
@@ -34,14 +65,55 @@ type JobInfo struct {
 	RetryDelay uint            `json:"retry_delay"`
 }
 
+// JobRequest describes a job to be pushed. RunAfter, Timeout and RetryDelay
+// are in seconds; zero values leave the server defaults in place.
+type JobRequest struct {
+	URL        string      `json:"url"`
+	Payload    interface{} `json:"payload,omitempty"`
+	RunAfter   uint        `json:"run_after,omitempty"`
+	Timeout    uint        `json:"timeout,omitempty"`
+	MaxRetries uint        `json:"max_retries,omitempty"`
+	RetryDelay uint        `json:"retry_delay,omitempty"`
+}
+
+type PushResult struct {
+	ID         uint64          `json:"id"`
+	QueueName  string          `json:"queue_name"`
+	Category   string          `json:"category"`
+	URL        string          `json:"url"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	RunAfter   uint            `json:"run_after"`
+	Timeout    uint            `json:"timeout"`
+	MaxRetries uint            `json:"max_retries"`
+	RetryDelay uint            `json:"retry_delay"`
+}
+
 type JobsInfo struct {
 	Jobs       []JobInfo `json:"jobs"`
 	NextCursor string    `json:"next_cursor"`
 }
 
+type JobResult struct {
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type FailedJobInfo struct {
+	ID        uint64          `json:"id"`
+	JobID     uint64          `json:"job_id"`
+	Category  string          `json:"category"`
+	URL       string          `json:"url"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Result    *JobResult      `json:"result"`
+	FailCount uint            `json:"fail_count"`
+	FailedAt  time.Time       `json:"failed_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
 type FailedJobsInfo struct {
-	FailedJobs []JobInfo `json:"failed_jobs"`
-	NextCursor string    `json:"next_cursor"`
+	FailedJobs []FailedJobInfo `json:"failed_jobs"`
+	NextCursor string          `json:"next_cursor"`
 }
 
 type NodeInfo struct {
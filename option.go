@@ -0,0 +1,90 @@
+package tsutsu
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+type Option func(*Tsutsu) error
+
+// Logger is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// RequestHook is called with every request right before it is sent.
+type RequestHook func(req *http.Request)
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(t *Tsutsu) error {
+		if client == nil {
+			return errors.New("tsutsu: http client must not be nil")
+		}
+		t.client = client
+		return nil
+	}
+}
+
+// WithTimeout bounds every call whose context has no deadline of its own.
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *Tsutsu) error {
+		if timeout < 0 {
+			return errors.New("tsutsu: timeout must not be negative")
+		}
+		t.timeout = timeout
+		return nil
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(t *Tsutsu) error {
+		t.userAgent = userAgent
+		return nil
+	}
+}
+
+func WithHeader(key, value string) Option {
+	return func(t *Tsutsu) error {
+		t.header.Add(key, value)
+		return nil
+	}
+}
+
+func WithHeaders(header http.Header) Option {
+	return func(t *Tsutsu) error {
+		for key, values := range header {
+			for _, value := range values {
+				t.header.Add(key, value)
+			}
+		}
+		return nil
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(t *Tsutsu) error {
+		if policy.MaxAttempts < 0 {
+			return errors.New("tsutsu: max attempts must not be negative")
+		}
+		t.retryPolicy = policy
+		return nil
+	}
+}
+
+func WithLogger(logger Logger) Option {
+	return func(t *Tsutsu) error {
+		t.logger = logger
+		return nil
+	}
+}
+
+func WithRequestHook(hook RequestHook) Option {
+	return func(t *Tsutsu) error {
+		if hook == nil {
+			return errors.New("tsutsu: request hook must not be nil")
+		}
+		t.requestHooks = append(t.requestHooks, hook)
+		return nil
+	}
+}
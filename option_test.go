@@ -0,0 +1,75 @@
+package tsutsu
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t1 *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	var hooked bool
+	t, err := New(server.URL,
+		WithHTTPClient(server.Client()),
+		WithTimeout(time.Second),
+		WithUserAgent("tsutsu-test"),
+		WithHeader("X-Trace-Id", "abc"),
+		WithRetryPolicy(DefaultRetryPolicy()),
+		WithLogger(log.New(&logs, "", 0)),
+		WithRequestHook(func(req *http.Request) {
+			if _, ok := req.Context().Deadline(); ok {
+				hooked = true
+			}
+		}),
+	)
+	if err != nil {
+		t1.Fatal(err)
+	}
+
+	if _, err := t.QueuesWithContext(context.Background()); err != nil {
+		t1.Fatal(err)
+	}
+	if got.Get("User-Agent") != "tsutsu-test" {
+		t1.Errorf("User-Agent = %q, want %q", got.Get("User-Agent"), "tsutsu-test")
+	}
+	if got.Get("X-Trace-Id") != "abc" {
+		t1.Errorf("X-Trace-Id = %q, want %q", got.Get("X-Trace-Id"), "abc")
+	}
+	if !hooked {
+		t1.Error("request hook was not called with a deadline")
+	}
+	if !strings.Contains(logs.String(), "GET "+server.URL+"/queues") {
+		t1.Errorf("log = %q, want request line", logs.String())
+	}
+}
+
+func TestNew_invalidOption(t1 *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "nil client", opt: WithHTTPClient(nil)},
+		{name: "negative timeout", opt: WithTimeout(-time.Second)},
+		{name: "negative attempts", opt: WithRetryPolicy(RetryPolicy{MaxAttempts: -1})},
+		{name: "nil hook", opt: WithRequestHook(nil)},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			if _, err := New("http://localhost", tt.opt); err == nil {
+				t1.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package tsutsu
+
+import (
+	"context"
+	"fmt"
+	"github.com/fireworq/fireworq/model"
+	"sort"
+	"strings"
+)
+
+// Topology is a set of queues and routings. A queue that specifies no
+// throttling keeps whatever throttling the server has for it.
+type Topology struct {
+	Queues   []QueueSpec
+	Routings []model.Routing
+}
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+type QueueChange struct {
+	Action  Action
+	Current QueueSpec
+	Desired QueueSpec
+}
+
+type RoutingChange struct {
+	Action  Action
+	Current model.Routing
+	Desired model.Routing
+}
+
+// SkippedQueue is a queue the plan would delete but keeps because it still
+// has jobs.
+type SkippedQueue struct {
+	Name   string
+	Reason string
+}
+
+type Plan struct {
+	QueueChanges   []QueueChange
+	RoutingChanges []RoutingChange
+	SkippedQueues  []SkippedQueue
+}
+
+func (p Plan) Empty() bool {
+	return len(p.QueueChanges) == 0 && len(p.RoutingChanges) == 0
+}
+
+// String renders the plan as a dry run.
+func (p Plan) String() string {
+	var b strings.Builder
+	for _, c := range p.QueueChanges {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(&b, "+ queue %s (%s)\n", c.Desired.Name, strings.Join(queueFields(c.Desired), ", "))
+		case ActionUpdate:
+			fmt.Fprintf(&b, "~ queue %s (%s)\n", c.Desired.Name, strings.Join(queueFieldChanges(c.Current, c.Desired), ", "))
+		case ActionDelete:
+			fmt.Fprintf(&b, "- queue %s\n", c.Current.Name)
+		}
+	}
+	for _, s := range p.SkippedQueues {
+		fmt.Fprintf(&b, "! queue %s kept: %s\n", s.Name, s.Reason)
+	}
+	for _, c := range p.RoutingChanges {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(&b, "+ routing %s -> %s\n", c.Desired.JobCategory, c.Desired.QueueName)
+		case ActionUpdate:
+			fmt.Fprintf(&b, "~ routing %s -> %s (was %s)\n", c.Desired.JobCategory, c.Desired.QueueName, c.Current.QueueName)
+		case ActionDelete:
+			fmt.Fprintf(&b, "- routing %s\n", c.Current.JobCategory)
+		}
+	}
+	if b.Len() == 0 {
+		return "no changes\n"
+	}
+	return b.String()
+}
+
+func queueFields(q QueueSpec) []string {
+	fields := []string{
+		fmt.Sprintf("polling_interval: %d", q.PollingInterval),
+		fmt.Sprintf("max_workers: %d", q.MaxWorkers),
+	}
+	if q.throttled() {
+		fields = append(fields,
+			fmt.Sprintf("max_dispatches_per_second: %g", q.MaxDispatchesPerSecond),
+			fmt.Sprintf("max_burst_size: %d", q.MaxBurstSize),
+		)
+	}
+	return fields
+}
+
+func queueFieldChanges(current, desired QueueSpec) []string {
+	var changes []string
+	if current.PollingInterval != desired.PollingInterval {
+		changes = append(changes, fmt.Sprintf("polling_interval: %d -> %d", current.PollingInterval, desired.PollingInterval))
+	}
+	if current.MaxWorkers != desired.MaxWorkers {
+		changes = append(changes, fmt.Sprintf("max_workers: %d -> %d", current.MaxWorkers, desired.MaxWorkers))
+	}
+	if current.MaxDispatchesPerSecond != desired.MaxDispatchesPerSecond {
+		changes = append(changes, fmt.Sprintf("max_dispatches_per_second: %g -> %g", current.MaxDispatchesPerSecond, desired.MaxDispatchesPerSecond))
+	}
+	if current.MaxBurstSize != desired.MaxBurstSize {
+		changes = append(changes, fmt.Sprintf("max_burst_size: %d -> %d", current.MaxBurstSize, desired.MaxBurstSize))
+	}
+	return changes
+}
+
+// keepThrottling gives desired the throttling of current when it specifies
+// none itself.
+func keepThrottling(desired, current QueueSpec) QueueSpec {
+	if !desired.throttled() {
+		desired.MaxDispatchesPerSecond = current.MaxDispatchesPerSecond
+		desired.MaxBurstSize = current.MaxBurstSize
+	}
+	return desired
+}
+
+// Reconciler brings the queues and routings of a server in line with a
+// desired Topology. Anything the topology does not mention is deleted, except
+// for the default queue of the server, which routings may refer to without
+// declaring it.
+type Reconciler struct {
+	client         Client
+	keepBusyQueues bool
+}
+
+func NewReconciler(client Client) *Reconciler {
+	return &Reconciler{client: client}
+}
+
+// KeepBusyQueues makes the plan keep queues that still have waiting or
+// deferred jobs, or whose jobs cannot be inspected, instead of deleting them.
+func (r *Reconciler) KeepBusyQueues() *Reconciler {
+	r.keepBusyQueues = true
+	return r
+}
+
+func (r *Reconciler) Plan(ctx context.Context, desired Topology) (Plan, error) {
+	settings, err := r.client.SettingsWithContext(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+	currentQueues, err := r.client.QueueSpecsWithContext(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+	currentRoutings, err := r.client.RoutingsWithContext(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	var plan Plan
+	queues := map[string]QueueSpec{}
+	for _, q := range currentQueues {
+		queues[q.Name] = q
+	}
+	desiredQueues := map[string]bool{}
+	for _, q := range sortedQueues(desired.Queues) {
+		if desiredQueues[q.Name] {
+			return Plan{}, fmt.Errorf("tsutsu: queue %s is defined more than once", q.Name)
+		}
+		desiredQueues[q.Name] = true
+
+		current, ok := queues[q.Name]
+		if ok {
+			q = keepThrottling(q, current)
+		}
+		switch {
+		case !ok:
+			plan.QueueChanges = append(plan.QueueChanges, QueueChange{Action: ActionCreate, Desired: q})
+		case current != q:
+			plan.QueueChanges = append(plan.QueueChanges, QueueChange{Action: ActionUpdate, Current: current, Desired: q})
+		}
+	}
+
+	keptQueues := map[string]bool{}
+	for _, q := range sortedQueues(currentQueues) {
+		if desiredQueues[q.Name] || q.Name == settings.QueueDefault {
+			continue
+		}
+		if r.keepBusyQueues {
+			reason, err := r.busy(ctx, q.Name)
+			if err != nil {
+				return Plan{}, err
+			}
+			if reason != "" {
+				keptQueues[q.Name] = true
+				plan.SkippedQueues = append(plan.SkippedQueues, SkippedQueue{Name: q.Name, Reason: reason})
+				continue
+			}
+		}
+		plan.QueueChanges = append(plan.QueueChanges, QueueChange{Action: ActionDelete, Current: q})
+	}
+
+	routings := map[string]model.Routing{}
+	for _, rt := range currentRoutings {
+		routings[rt.JobCategory] = rt
+	}
+	desiredRoutings := map[string]bool{}
+	for _, rt := range sortedRoutings(desired.Routings) {
+		if desiredRoutings[rt.JobCategory] {
+			return Plan{}, fmt.Errorf("tsutsu: routing %s is defined more than once", rt.JobCategory)
+		}
+		desiredRoutings[rt.JobCategory] = true
+
+		if !desiredQueues[rt.QueueName] && !keptQueues[rt.QueueName] && rt.QueueName != settings.QueueDefault {
+			return Plan{}, fmt.Errorf("tsutsu: routing %s refers to undefined queue %s", rt.JobCategory, rt.QueueName)
+		}
+
+		current, ok := routings[rt.JobCategory]
+		switch {
+		case !ok:
+			plan.RoutingChanges = append(plan.RoutingChanges, RoutingChange{Action: ActionCreate, Desired: rt})
+		case current != rt:
+			plan.RoutingChanges = append(plan.RoutingChanges, RoutingChange{Action: ActionUpdate, Current: current, Desired: rt})
+		}
+	}
+	for _, rt := range sortedRoutings(currentRoutings) {
+		if !desiredRoutings[rt.JobCategory] {
+			plan.RoutingChanges = append(plan.RoutingChanges, RoutingChange{Action: ActionDelete, Current: rt})
+		}
+	}
+
+	return plan, nil
+}
+
+func (r *Reconciler) busy(ctx context.Context, queueName string) (string, error) {
+	lists := []struct {
+		name string
+		list func(context.Context, string) (JobsInfo, error)
+	}{
+		{name: "waiting", list: r.client.Jobs().Limit(1).WaitingWithContext},
+		{name: "deferred", list: r.client.Jobs().Limit(1).DeferredWithContext},
+	}
+	for _, l := range lists {
+		jobs, err := l.list(ctx, queueName)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", err
+			}
+			return fmt.Sprintf("cannot inspect %s jobs: %v", l.name, err), nil
+		}
+		if len(jobs.Jobs) > 0 {
+			return "has " + l.name + " jobs", nil
+		}
+	}
+	return "", nil
+}
+
+// Apply creates and updates queues before the routings that refer to them,
+// and deletes routings before the queues they point at.
+func (r *Reconciler) Apply(ctx context.Context, plan Plan) error {
+	for _, c := range plan.QueueChanges {
+		if c.Action == ActionDelete {
+			continue
+		}
+		if _, err := r.client.PutQueueWithContext(ctx, c.Desired); err != nil {
+			return err
+		}
+	}
+	for _, c := range plan.RoutingChanges {
+		if c.Action == ActionDelete {
+			continue
+		}
+		if _, err := r.client.CreateRoutingWithContext(ctx, c.Desired.JobCategory, c.Desired.QueueName); err != nil {
+			return err
+		}
+	}
+	for _, c := range plan.RoutingChanges {
+		if c.Action != ActionDelete {
+			continue
+		}
+		if _, err := r.client.DeleteRoutingWithContext(ctx, c.Current.JobCategory); err != nil {
+			return err
+		}
+	}
+	for _, c := range plan.QueueChanges {
+		if c.Action != ActionDelete {
+			continue
+		}
+		if _, err := r.client.DeleteQueueWithContext(ctx, c.Current.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedQueues(queues []QueueSpec) []QueueSpec {
+	sorted := append([]QueueSpec(nil), queues...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func sortedRoutings(routings []model.Routing) []model.Routing {
+	sorted := append([]model.Routing(nil), routings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].JobCategory < sorted[j].JobCategory })
+	return sorted
+}
@@ -0,0 +1,214 @@
+package tsutsu_test
+
+import (
+	"context"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"github.com/stk132/tsutsu/tsutsutest"
+	"reflect"
+	"testing"
+)
+
+func TestReconciler(t1 *testing.T) {
+	ctx := context.Background()
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	for _, q := range []model.Queue{
+		{Name: "mail", PollingInterval: 100, MaxWorkers: 1},
+		{Name: "stale", PollingInterval: 100, MaxWorkers: 1},
+		{Name: "busy", PollingInterval: 100, MaxWorkers: 1},
+	} {
+		if _, err := t.CreateQueue(q.Name, q.PollingInterval, q.MaxWorkers); err != nil {
+			t1.Fatal(err)
+		}
+	}
+	for _, rt := range []model.Routing{
+		{JobCategory: "mail.send", QueueName: "mail"},
+		{JobCategory: "legacy", QueueName: "stale"},
+		{JobCategory: "busy.job", QueueName: "busy"},
+	} {
+		if _, err := t.CreateRouting(rt.JobCategory, rt.QueueName); err != nil {
+			t1.Fatal(err)
+		}
+	}
+	if _, err := t.PushJob("busy.job", tsutsu.JobRequest{URL: "http://localhost/"}); err != nil {
+		t1.Fatal(err)
+	}
+
+	desired := tsutsu.Topology{
+		Queues: []tsutsu.QueueSpec{
+			{Name: "default", PollingInterval: 200, MaxWorkers: 20},
+			{Name: "mail", PollingInterval: 100, MaxWorkers: 5},
+			{Name: "report", PollingInterval: 1000, MaxWorkers: 1},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "mail.send", QueueName: "mail"},
+			{JobCategory: "report.daily", QueueName: "report"},
+		},
+	}
+
+	r := tsutsu.NewReconciler(t).KeepBusyQueues()
+	plan, err := r.Plan(ctx, desired)
+	if err != nil {
+		t1.Fatal(err)
+	}
+
+	wantPlan := `~ queue mail (max_workers: 1 -> 5)
++ queue report (polling_interval: 1000, max_workers: 1)
+- queue stale
+! queue busy kept: has waiting jobs
++ routing report.daily -> report
+- routing busy.job
+- routing legacy
+`
+	if plan.String() != wantPlan {
+		t1.Errorf("Plan() got =\n%s\nwant\n%s", plan, wantPlan)
+	}
+
+	if err := r.Apply(ctx, plan); err != nil {
+		t1.Fatal(err)
+	}
+
+	queues, err := t.Queues()
+	if err != nil {
+		t1.Fatal(err)
+	}
+	wantQueues := []model.Queue{
+		{Name: "busy", PollingInterval: 100, MaxWorkers: 1},
+		{Name: "default", PollingInterval: 200, MaxWorkers: 20},
+		{Name: "mail", PollingInterval: 100, MaxWorkers: 5},
+		{Name: "report", PollingInterval: 1000, MaxWorkers: 1},
+	}
+	if !reflect.DeepEqual(queues, wantQueues) {
+		t1.Errorf("Queues() got = %v, want %v", queues, wantQueues)
+	}
+
+	plan, err = r.Plan(ctx, desired)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if !plan.Empty() {
+		t1.Errorf("Plan() after Apply got =\n%s", plan)
+	}
+}
+
+func TestReconciler_Plan_invalid(t1 *testing.T) {
+	s := tsutsutest.NewServer()
+	defer s.Close()
+
+	tests := []struct {
+		name    string
+		desired tsutsu.Topology
+	}{
+		{
+			name: "duplicated queue",
+			desired: tsutsu.Topology{Queues: []tsutsu.QueueSpec{
+				{Name: "default", PollingInterval: 200, MaxWorkers: 20},
+				{Name: "default", PollingInterval: 100, MaxWorkers: 20},
+			}},
+		},
+		{
+			name: "routing to undefined queue",
+			desired: tsutsu.Topology{
+				Queues:   []tsutsu.QueueSpec{{Name: "default", PollingInterval: 200, MaxWorkers: 20}},
+				Routings: []model.Routing{{JobCategory: "mail.send", QueueName: "mail"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			if _, err := tsutsu.NewReconciler(s.Tsutsu()).Plan(context.Background(), tt.desired); err == nil {
+				t1.Error("Plan() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestReconciler_defaultQueue(t1 *testing.T) {
+	ctx := context.Background()
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	if _, err := t.CreateQueue("mail", 100, 5); err != nil {
+		t1.Fatal(err)
+	}
+
+	desired := tsutsu.Topology{
+		Queues: []tsutsu.QueueSpec{
+			{Name: "mail", PollingInterval: 100, MaxWorkers: 5},
+		},
+		Routings: []model.Routing{
+			{JobCategory: "mail.send", QueueName: "mail"},
+			{JobCategory: "report.daily", QueueName: "default"},
+		},
+	}
+
+	r := tsutsu.NewReconciler(t)
+	plan, err := r.Plan(ctx, desired)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	wantPlan := `+ routing mail.send -> mail
++ routing report.daily -> default
+`
+	if plan.String() != wantPlan {
+		t1.Errorf("Plan() got =\n%s\nwant\n%s", plan, wantPlan)
+	}
+
+	if err := r.Apply(ctx, plan); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := t.Queue("default"); err != nil {
+		t1.Errorf("Queue(default) error = %v, want the default queue kept", err)
+	}
+}
+
+func TestReconciler_throttling(t1 *testing.T) {
+	ctx := context.Background()
+	s := tsutsutest.NewServer()
+	defer s.Close()
+	s.SetVersion("1.5.0")
+	t := s.Tsutsu()
+
+	if _, err := t.PutQueue(tsutsu.QueueSpec{Name: "mail", PollingInterval: 100, MaxWorkers: 4, MaxDispatchesPerSecond: 2.5, MaxBurstSize: 5}); err != nil {
+		t1.Fatal(err)
+	}
+
+	desired := tsutsu.Topology{
+		Queues: []tsutsu.QueueSpec{
+			{Name: "mail", PollingInterval: 100, MaxWorkers: 8},
+			{Name: "report", PollingInterval: 1000, MaxWorkers: 1, MaxDispatchesPerSecond: 0.5, MaxBurstSize: 1},
+		},
+	}
+
+	r := tsutsu.NewReconciler(t)
+	plan, err := r.Plan(ctx, desired)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	wantPlan := `~ queue mail (max_workers: 4 -> 8)
++ queue report (polling_interval: 1000, max_workers: 1, max_dispatches_per_second: 0.5, max_burst_size: 1)
+`
+	if plan.String() != wantPlan {
+		t1.Errorf("Plan() got =\n%s\nwant\n%s", plan, wantPlan)
+	}
+
+	if err := r.Apply(ctx, plan); err != nil {
+		t1.Fatal(err)
+	}
+	queues, err := t.QueueSpecs()
+	if err != nil {
+		t1.Fatal(err)
+	}
+	wantQueues := []tsutsu.QueueSpec{
+		{Name: "default", PollingInterval: 200, MaxWorkers: 20},
+		{Name: "mail", PollingInterval: 100, MaxWorkers: 8, MaxDispatchesPerSecond: 2.5, MaxBurstSize: 5},
+		{Name: "report", PollingInterval: 1000, MaxWorkers: 1, MaxDispatchesPerSecond: 0.5, MaxBurstSize: 1},
+	}
+	if !reflect.DeepEqual(queues, wantQueues) {
+		t1.Errorf("QueueSpecs() got = %v, want %v", queues, wantQueues)
+	}
+}
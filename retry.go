@@ -0,0 +1,151 @@
+package tsutsu
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how a request is retried after a network error or a
+// retryable status code. MaxAttempts includes the first attempt, so values
+// below 2 disable retries.
+//
+// Requests that are not idempotent (job pushes) are only retried when the
+// connection could not be established, because otherwise the job may have
+// been enqueued already. Set RetryNonIdempotent to retry them regardless.
+type RetryPolicy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	Multiplier           float64
+	Jitter               float64
+	RetryableStatusCodes []int
+	RetryNonIdempotent   bool
+}
+
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+func (p RetryPolicy) retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	idempotent := req.Method != http.MethodPost || p.RetryNonIdempotent
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+		return idempotent || isDialError(err)
+	}
+
+	if !idempotent {
+		return false
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if res.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func (t *Tsutsu) send(req *http.Request) (*http.Response, error) {
+	policy := t.retryPolicy
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res, err := t.client.Do(req)
+		t.logAttempt(req, res, err, attempt, time.Since(start))
+		if attempt >= policy.MaxAttempts || !policy.retryable(req, res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		if err := sleep(req.Context(), policy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *Tsutsu) logAttempt(req *http.Request, res *http.Response, err error, attempt int, elapsed time.Duration) {
+	if t.logger == nil {
+		return
+	}
+
+	result := ""
+	if err != nil {
+		result = err.Error()
+	} else {
+		result = res.Status
+	}
+	t.logger.Printf("tsutsu: %s %s (attempt %d): %s in %s", req.Method, req.URL, attempt, result, elapsed)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package tsutsu
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRetryPolicy(t1 *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = time.Millisecond
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		failures     int32
+		call         func(t *Tsutsu) error
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name:     "get should be retried",
+			policy:   policy,
+			failures: 2,
+			call: func(t *Tsutsu) error {
+				_, err := t.Queues()
+				return err
+			},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:     "get should give up after max attempts",
+			policy:   policy,
+			failures: 3,
+			call: func(t *Tsutsu) error {
+				_, err := t.Queues()
+				return err
+			},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:     "put should be retried with its body",
+			policy:   policy,
+			failures: 1,
+			call: func(t *Tsutsu) error {
+				_, err := t.CreateQueue("test_queue", 100, 1)
+				return err
+			},
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+		{
+			name:     "push should not be retried",
+			policy:   policy,
+			failures: 1,
+			call: func(t *Tsutsu) error {
+				_, err := t.PushJob("test_category", JobRequest{URL: "http://localhost/"})
+				return err
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:     "no retry should be attempted once",
+			policy:   NoRetry,
+			failures: 1,
+			call: func(t *Tsutsu) error {
+				_, err := t.Queues()
+				return err
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				if r.Method == http.MethodPut {
+					io.Copy(w, r.Body)
+					return
+				}
+				io.WriteString(w, `[]`)
+			}))
+			defer server.Close()
+
+			t, err := New(server.URL, WithRetryPolicy(tt.policy))
+			if err != nil {
+				t1.Fatal(err)
+			}
+			err = tt.call(t)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t1.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t1 *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := policy.backoff(tt.attempt); got != tt.want {
+			t1.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package tsutsutest
+
+import (
+	"context"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"sync"
+)
+
+// Call is a method call recorded by MockClient and MockJobInspector. Method
+// is the name without the WithContext suffix, and Args leave out the context
+// and iteration callbacks.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// MockClient implements tsutsu.Client. Each method calls the matching Func
+// field when it is set, and returns zero values otherwise.
+type MockClient struct {
+	recorder
+
+	QueuesFunc        func(ctx context.Context) ([]model.Queue, error)
+	QueueSpecsFunc    func(ctx context.Context) ([]tsutsu.QueueSpec, error)
+	QueueFunc         func(ctx context.Context, name string) (model.Queue, error)
+	StatsFunc         func(ctx context.Context, queueName string) (tsutsu.QueueStats, error)
+	AllStatsFunc      func(ctx context.Context) (map[string]tsutsu.QueueStats, error)
+	NodeFunc          func(ctx context.Context, queueName string) (tsutsu.NodeInfo, error)
+	CreateQueueFunc   func(ctx context.Context, name string, pollingInterval, maxWorkers uint) (model.Queue, error)
+	PutQueueFunc      func(ctx context.Context, spec tsutsu.QueueSpec) (tsutsu.QueueSpec, error)
+	DeleteQueueFunc   func(ctx context.Context, name string) (model.Queue, error)
+	RoutingsFunc      func(ctx context.Context) ([]model.Routing, error)
+	RoutingFunc       func(ctx context.Context, jobCategory string) (model.Routing, error)
+	CreateRoutingFunc func(ctx context.Context, jobCategory, queueName string) (model.Routing, error)
+	DeleteRoutingFunc func(ctx context.Context, jobCategory string) (model.Routing, error)
+	PushJobFunc       func(ctx context.Context, category string, job tsutsu.JobRequest) (tsutsu.PushResult, error)
+	VersionFunc       func(ctx context.Context) (tsutsu.ServerVersion, error)
+	SettingsFunc      func(ctx context.Context) (tsutsu.Settings, error)
+
+	JobInspector *MockJobInspector
+}
+
+func (m *MockClient) Queues() ([]model.Queue, error) {
+	return m.QueuesWithContext(context.Background())
+}
+
+func (m *MockClient) QueuesWithContext(ctx context.Context) ([]model.Queue, error) {
+	m.record("Queues")
+	if m.QueuesFunc != nil {
+		return m.QueuesFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) QueueSpecs() ([]tsutsu.QueueSpec, error) {
+	return m.QueueSpecsWithContext(context.Background())
+}
+
+func (m *MockClient) QueueSpecsWithContext(ctx context.Context) ([]tsutsu.QueueSpec, error) {
+	m.record("QueueSpecs")
+	if m.QueueSpecsFunc != nil {
+		return m.QueueSpecsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) Queue(name string) (model.Queue, error) {
+	return m.QueueWithContext(context.Background(), name)
+}
+
+func (m *MockClient) QueueWithContext(ctx context.Context, name string) (model.Queue, error) {
+	m.record("Queue", name)
+	if m.QueueFunc != nil {
+		return m.QueueFunc(ctx, name)
+	}
+	return model.Queue{}, nil
+}
+
+func (m *MockClient) Stats(queueName string) (tsutsu.QueueStats, error) {
+	return m.StatsWithContext(context.Background(), queueName)
+}
+
+func (m *MockClient) StatsWithContext(ctx context.Context, queueName string) (tsutsu.QueueStats, error) {
+	m.record("Stats", queueName)
+	if m.StatsFunc != nil {
+		return m.StatsFunc(ctx, queueName)
+	}
+	return tsutsu.QueueStats{}, nil
+}
+
+func (m *MockClient) AllStats() (map[string]tsutsu.QueueStats, error) {
+	return m.AllStatsWithContext(context.Background())
+}
+
+func (m *MockClient) AllStatsWithContext(ctx context.Context) (map[string]tsutsu.QueueStats, error) {
+	m.record("AllStats")
+	if m.AllStatsFunc != nil {
+		return m.AllStatsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) Node(queueName string) (tsutsu.NodeInfo, error) {
+	return m.NodeWithContext(context.Background(), queueName)
+}
+
+func (m *MockClient) NodeWithContext(ctx context.Context, queueName string) (tsutsu.NodeInfo, error) {
+	m.record("Node", queueName)
+	if m.NodeFunc != nil {
+		return m.NodeFunc(ctx, queueName)
+	}
+	return tsutsu.NodeInfo{}, nil
+}
+
+func (m *MockClient) CreateQueue(name string, pollingInterval, maxWorkers uint) (model.Queue, error) {
+	return m.CreateQueueWithContext(context.Background(), name, pollingInterval, maxWorkers)
+}
+
+func (m *MockClient) CreateQueueWithContext(ctx context.Context, name string, pollingInterval, maxWorkers uint) (model.Queue, error) {
+	m.record("CreateQueue", name, pollingInterval, maxWorkers)
+	if m.CreateQueueFunc != nil {
+		return m.CreateQueueFunc(ctx, name, pollingInterval, maxWorkers)
+	}
+	return model.Queue{}, nil
+}
+
+func (m *MockClient) PutQueue(spec tsutsu.QueueSpec) (tsutsu.QueueSpec, error) {
+	return m.PutQueueWithContext(context.Background(), spec)
+}
+
+func (m *MockClient) PutQueueWithContext(ctx context.Context, spec tsutsu.QueueSpec) (tsutsu.QueueSpec, error) {
+	m.record("PutQueue", spec)
+	if m.PutQueueFunc != nil {
+		return m.PutQueueFunc(ctx, spec)
+	}
+	return tsutsu.QueueSpec{}, nil
+}
+
+func (m *MockClient) DeleteQueue(name string) (model.Queue, error) {
+	return m.DeleteQueueWithContext(context.Background(), name)
+}
+
+func (m *MockClient) DeleteQueueWithContext(ctx context.Context, name string) (model.Queue, error) {
+	m.record("DeleteQueue", name)
+	if m.DeleteQueueFunc != nil {
+		return m.DeleteQueueFunc(ctx, name)
+	}
+	return model.Queue{}, nil
+}
+
+func (m *MockClient) Routings() ([]model.Routing, error) {
+	return m.RoutingsWithContext(context.Background())
+}
+
+func (m *MockClient) RoutingsWithContext(ctx context.Context) ([]model.Routing, error) {
+	m.record("Routings")
+	if m.RoutingsFunc != nil {
+		return m.RoutingsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) Routing(jobCategory string) (model.Routing, error) {
+	return m.RoutingWithContext(context.Background(), jobCategory)
+}
+
+func (m *MockClient) RoutingWithContext(ctx context.Context, jobCategory string) (model.Routing, error) {
+	m.record("Routing", jobCategory)
+	if m.RoutingFunc != nil {
+		return m.RoutingFunc(ctx, jobCategory)
+	}
+	return model.Routing{}, nil
+}
+
+func (m *MockClient) CreateRouting(jobCategory, queueName string) (model.Routing, error) {
+	return m.CreateRoutingWithContext(context.Background(), jobCategory, queueName)
+}
+
+func (m *MockClient) CreateRoutingWithContext(ctx context.Context, jobCategory, queueName string) (model.Routing, error) {
+	m.record("CreateRouting", jobCategory, queueName)
+	if m.CreateRoutingFunc != nil {
+		return m.CreateRoutingFunc(ctx, jobCategory, queueName)
+	}
+	return model.Routing{}, nil
+}
+
+func (m *MockClient) DeleteRouting(jobCategory string) (model.Routing, error) {
+	return m.DeleteRoutingWithContext(context.Background(), jobCategory)
+}
+
+func (m *MockClient) DeleteRoutingWithContext(ctx context.Context, jobCategory string) (model.Routing, error) {
+	m.record("DeleteRouting", jobCategory)
+	if m.DeleteRoutingFunc != nil {
+		return m.DeleteRoutingFunc(ctx, jobCategory)
+	}
+	return model.Routing{}, nil
+}
+
+func (m *MockClient) PushJob(category string, job tsutsu.JobRequest) (tsutsu.PushResult, error) {
+	return m.PushJobWithContext(context.Background(), category, job)
+}
+
+func (m *MockClient) PushJobWithContext(ctx context.Context, category string, job tsutsu.JobRequest) (tsutsu.PushResult, error) {
+	m.record("PushJob", category, job)
+	if m.PushJobFunc != nil {
+		return m.PushJobFunc(ctx, category, job)
+	}
+	return tsutsu.PushResult{}, nil
+}
+
+func (m *MockClient) Version() (tsutsu.ServerVersion, error) {
+	return m.VersionWithContext(context.Background())
+}
+
+func (m *MockClient) VersionWithContext(ctx context.Context) (tsutsu.ServerVersion, error) {
+	m.record("Version")
+	if m.VersionFunc != nil {
+		return m.VersionFunc(ctx)
+	}
+	return tsutsu.ServerVersion{}, nil
+}
+
+func (m *MockClient) Settings() (tsutsu.Settings, error) {
+	return m.SettingsWithContext(context.Background())
+}
+
+func (m *MockClient) SettingsWithContext(ctx context.Context) (tsutsu.Settings, error) {
+	m.record("Settings")
+	if m.SettingsFunc != nil {
+		return m.SettingsFunc(ctx)
+	}
+	return tsutsu.Settings{}, nil
+}
+
+// Jobs returns m.JobInspector, creating it on first use.
+func (m *MockClient) Jobs() tsutsu.JobInspection {
+	m.record("Jobs")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.JobInspector == nil {
+		m.JobInspector = &MockJobInspector{}
+	}
+	return m.JobInspector
+}
+
+// MockJobInspector implements tsutsu.JobInspection.
+type MockJobInspector struct {
+	recorder
+
+	GrabbedFunc      func(ctx context.Context, queueName string) (tsutsu.JobsInfo, error)
+	WaitingFunc      func(ctx context.Context, queueName string) (tsutsu.JobsInfo, error)
+	DeferredFunc     func(ctx context.Context, queueName string) (tsutsu.JobsInfo, error)
+	FailedFunc       func(ctx context.Context, queueName string) (tsutsu.FailedJobsInfo, error)
+	GetFunc          func(ctx context.Context, queueName string, id uint64) (tsutsu.JobInfo, error)
+	DeleteFunc       func(ctx context.Context, queueName string, id uint64) (tsutsu.JobInfo, error)
+	GetFailedFunc    func(ctx context.Context, queueName string, id uint64) (tsutsu.FailedJobInfo, error)
+	DeleteFailedFunc func(ctx context.Context, queueName string, id uint64) (tsutsu.FailedJobInfo, error)
+	GrabbedAllFunc   func(ctx context.Context, queueName string, fn func(tsutsu.JobInfo) error) error
+	WaitingAllFunc   func(ctx context.Context, queueName string, fn func(tsutsu.JobInfo) error) error
+	DeferredAllFunc  func(ctx context.Context, queueName string, fn func(tsutsu.JobInfo) error) error
+	FailedAllFunc    func(ctx context.Context, queueName string, fn func(tsutsu.FailedJobInfo) error) error
+}
+
+func (m *MockJobInspector) Limit(limit uint) tsutsu.JobInspection {
+	m.record("Limit", limit)
+	return m
+}
+
+func (m *MockJobInspector) Asc() tsutsu.JobInspection {
+	m.record("Asc")
+	return m
+}
+
+func (m *MockJobInspector) Desc() tsutsu.JobInspection {
+	m.record("Desc")
+	return m
+}
+
+func (m *MockJobInspector) Cursor(cursor string) tsutsu.JobInspection {
+	m.record("Cursor", cursor)
+	return m
+}
+
+func (m *MockJobInspector) Grabbed(queueName string) (tsutsu.JobsInfo, error) {
+	return m.GrabbedWithContext(context.Background(), queueName)
+}
+
+func (m *MockJobInspector) GrabbedWithContext(ctx context.Context, queueName string) (tsutsu.JobsInfo, error) {
+	m.record("Grabbed", queueName)
+	if m.GrabbedFunc != nil {
+		return m.GrabbedFunc(ctx, queueName)
+	}
+	return tsutsu.JobsInfo{}, nil
+}
+
+func (m *MockJobInspector) Waiting(queueName string) (tsutsu.JobsInfo, error) {
+	return m.WaitingWithContext(context.Background(), queueName)
+}
+
+func (m *MockJobInspector) WaitingWithContext(ctx context.Context, queueName string) (tsutsu.JobsInfo, error) {
+	m.record("Waiting", queueName)
+	if m.WaitingFunc != nil {
+		return m.WaitingFunc(ctx, queueName)
+	}
+	return tsutsu.JobsInfo{}, nil
+}
+
+func (m *MockJobInspector) Deferred(queueName string) (tsutsu.JobsInfo, error) {
+	return m.DeferredWithContext(context.Background(), queueName)
+}
+
+func (m *MockJobInspector) DeferredWithContext(ctx context.Context, queueName string) (tsutsu.JobsInfo, error) {
+	m.record("Deferred", queueName)
+	if m.DeferredFunc != nil {
+		return m.DeferredFunc(ctx, queueName)
+	}
+	return tsutsu.JobsInfo{}, nil
+}
+
+func (m *MockJobInspector) Failed(queueName string) (tsutsu.FailedJobsInfo, error) {
+	return m.FailedWithContext(context.Background(), queueName)
+}
+
+func (m *MockJobInspector) FailedWithContext(ctx context.Context, queueName string) (tsutsu.FailedJobsInfo, error) {
+	m.record("Failed", queueName)
+	if m.FailedFunc != nil {
+		return m.FailedFunc(ctx, queueName)
+	}
+	return tsutsu.FailedJobsInfo{}, nil
+}
+
+func (m *MockJobInspector) Get(queueName string, id uint64) (tsutsu.JobInfo, error) {
+	return m.GetWithContext(context.Background(), queueName, id)
+}
+
+func (m *MockJobInspector) GetWithContext(ctx context.Context, queueName string, id uint64) (tsutsu.JobInfo, error) {
+	m.record("Get", queueName, id)
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, queueName, id)
+	}
+	return tsutsu.JobInfo{}, nil
+}
+
+func (m *MockJobInspector) Delete(queueName string, id uint64) (tsutsu.JobInfo, error) {
+	return m.DeleteWithContext(context.Background(), queueName, id)
+}
+
+func (m *MockJobInspector) DeleteWithContext(ctx context.Context, queueName string, id uint64) (tsutsu.JobInfo, error) {
+	m.record("Delete", queueName, id)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, queueName, id)
+	}
+	return tsutsu.JobInfo{}, nil
+}
+
+func (m *MockJobInspector) GetFailed(queueName string, id uint64) (tsutsu.FailedJobInfo, error) {
+	return m.GetFailedWithContext(context.Background(), queueName, id)
+}
+
+func (m *MockJobInspector) GetFailedWithContext(ctx context.Context, queueName string, id uint64) (tsutsu.FailedJobInfo, error) {
+	m.record("GetFailed", queueName, id)
+	if m.GetFailedFunc != nil {
+		return m.GetFailedFunc(ctx, queueName, id)
+	}
+	return tsutsu.FailedJobInfo{}, nil
+}
+
+func (m *MockJobInspector) DeleteFailed(queueName string, id uint64) (tsutsu.FailedJobInfo, error) {
+	return m.DeleteFailedWithContext(context.Background(), queueName, id)
+}
+
+func (m *MockJobInspector) DeleteFailedWithContext(ctx context.Context, queueName string, id uint64) (tsutsu.FailedJobInfo, error) {
+	m.record("DeleteFailed", queueName, id)
+	if m.DeleteFailedFunc != nil {
+		return m.DeleteFailedFunc(ctx, queueName, id)
+	}
+	return tsutsu.FailedJobInfo{}, nil
+}
+
+func (m *MockJobInspector) GrabbedAll(queueName string, fn func(tsutsu.JobInfo) error) error {
+	return m.GrabbedAllWithContext(context.Background(), queueName, fn)
+}
+
+func (m *MockJobInspector) GrabbedAllWithContext(ctx context.Context, queueName string, fn func(tsutsu.JobInfo) error) error {
+	m.record("GrabbedAll", queueName)
+	if m.GrabbedAllFunc != nil {
+		return m.GrabbedAllFunc(ctx, queueName, fn)
+	}
+	return nil
+}
+
+func (m *MockJobInspector) WaitingAll(queueName string, fn func(tsutsu.JobInfo) error) error {
+	return m.WaitingAllWithContext(context.Background(), queueName, fn)
+}
+
+func (m *MockJobInspector) WaitingAllWithContext(ctx context.Context, queueName string, fn func(tsutsu.JobInfo) error) error {
+	m.record("WaitingAll", queueName)
+	if m.WaitingAllFunc != nil {
+		return m.WaitingAllFunc(ctx, queueName, fn)
+	}
+	return nil
+}
+
+func (m *MockJobInspector) DeferredAll(queueName string, fn func(tsutsu.JobInfo) error) error {
+	return m.DeferredAllWithContext(context.Background(), queueName, fn)
+}
+
+func (m *MockJobInspector) DeferredAllWithContext(ctx context.Context, queueName string, fn func(tsutsu.JobInfo) error) error {
+	m.record("DeferredAll", queueName)
+	if m.DeferredAllFunc != nil {
+		return m.DeferredAllFunc(ctx, queueName, fn)
+	}
+	return nil
+}
+
+func (m *MockJobInspector) FailedAll(queueName string, fn func(tsutsu.FailedJobInfo) error) error {
+	return m.FailedAllWithContext(context.Background(), queueName, fn)
+}
+
+func (m *MockJobInspector) FailedAllWithContext(ctx context.Context, queueName string, fn func(tsutsu.FailedJobInfo) error) error {
+	m.record("FailedAll", queueName)
+	if m.FailedAllFunc != nil {
+		return m.FailedAllFunc(ctx, queueName, fn)
+	}
+	return nil
+}
+
+var (
+	_ tsutsu.Client        = (*MockClient)(nil)
+	_ tsutsu.JobInspection = (*MockJobInspector)(nil)
+)
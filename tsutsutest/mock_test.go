@@ -0,0 +1,82 @@
+package tsutsutest
+
+import (
+	"context"
+	"errors"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"reflect"
+	"testing"
+)
+
+func drainQueue(client tsutsu.Client, name string) (int, error) {
+	if _, err := client.Queue(name); err != nil {
+		return 0, err
+	}
+	var ids []uint64
+	err := client.Jobs().Limit(10).WaitingAll(name, func(job tsutsu.JobInfo) error {
+		ids = append(ids, job.ID)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if _, err := client.Jobs().Delete(name, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+func TestMockClient(t1 *testing.T) {
+	m := &MockClient{
+		QueueFunc: func(_ context.Context, name string) (model.Queue, error) {
+			return model.Queue{Name: name}, nil
+		},
+		JobInspector: &MockJobInspector{
+			WaitingAllFunc: func(_ context.Context, queueName string, fn func(tsutsu.JobInfo) error) error {
+				for _, id := range []uint64{1, 2} {
+					if err := fn(tsutsu.JobInfo{ID: id}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	got, err := drainQueue(m, "default")
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if got != 2 {
+		t1.Errorf("drainQueue() got = %d, want 2", got)
+	}
+
+	wantCalls := []Call{
+		{Method: "Queue", Args: []interface{}{"default"}},
+		{Method: "Jobs"},
+		{Method: "Jobs"},
+		{Method: "Jobs"},
+	}
+	if !reflect.DeepEqual(m.Calls(), wantCalls) {
+		t1.Errorf("Calls() got = %v, want %v", m.Calls(), wantCalls)
+	}
+	wantJobCalls := []Call{
+		{Method: "Limit", Args: []interface{}{uint(10)}},
+		{Method: "WaitingAll", Args: []interface{}{"default"}},
+		{Method: "Delete", Args: []interface{}{"default", uint64(1)}},
+		{Method: "Delete", Args: []interface{}{"default", uint64(2)}},
+	}
+	if !reflect.DeepEqual(m.JobInspector.Calls(), wantJobCalls) {
+		t1.Errorf("JobInspector.Calls() got = %v, want %v", m.JobInspector.Calls(), wantJobCalls)
+	}
+
+	m.QueueFunc = func(_ context.Context, name string) (model.Queue, error) {
+		return model.Queue{}, tsutsu.ErrNotFound
+	}
+	if _, err := drainQueue(m, "default"); !errors.Is(err, tsutsu.ErrNotFound) {
+		t1.Errorf("drainQueue() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,555 @@
+// Package tsutsutest provides an in-memory fake of the Fireworq HTTP API for
+// testing code that depends on tsutsu without running Fireworq.
+package tsutsutest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultQueueName = "default"
+	// DefaultVersion is the Fireworq release this module is built against.
+	// Tests of features gated on a later release set it with SetVersion.
+	DefaultVersion = "1.4.0"
+
+	statusClaimed = "claimed"
+	statusGrabbed = "grabbed"
+)
+
+type injectedError struct {
+	method  string
+	pattern string
+	status  int
+	message string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	defaultQueue string
+	queues       map[string]tsutsu.QueueSpec
+	routings     map[string]string
+	jobs         map[string]map[uint64]*tsutsu.JobInfo
+	failed       map[string]map[uint64]*tsutsu.FailedJobInfo
+	stats        map[string]*tsutsu.QueueStats
+	nextJobID    uint64
+	nextFailedID uint64
+	version      string
+	latency      time.Duration
+	errors       []injectedError
+}
+
+// NewServer starts a fake Fireworq with a "default" queue that receives jobs
+// of categories without a routing, like `fireworq --queue-default=default`.
+func NewServer() *Server {
+	s := &Server{
+		defaultQueue: DefaultQueueName,
+		queues:       map[string]tsutsu.QueueSpec{},
+		routings:     map[string]string{},
+		jobs:         map[string]map[uint64]*tsutsu.JobInfo{},
+		failed:       map[string]map[uint64]*tsutsu.FailedJobInfo{},
+		stats:        map[string]*tsutsu.QueueStats{},
+		version:      DefaultVersion,
+	}
+	s.addQueue(tsutsu.QueueSpec{Name: DefaultQueueName, PollingInterval: 200, MaxWorkers: 20})
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Tsutsu returns a client talking to the fake server.
+func (s *Server) Tsutsu() *tsutsu.Tsutsu {
+	return tsutsu.NewTsutsuWithClient(s.URL, s.Server.Client())
+}
+
+// SetVersion changes the version reported by GET /version.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = version
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// InjectError makes requests matching method and pattern fail with status.
+// An empty method matches any method, and pattern is matched against the
+// request path with path.Match (e.g. "/queue/*/stats").
+func (s *Server) InjectError(method, pattern string, status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, injectedError{method: method, pattern: pattern, status: status, message: message})
+}
+
+func (s *Server) ClearErrors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = nil
+}
+
+// Grab marks a job as grabbed by a worker.
+func (s *Server) Grab(queueName string, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[queueName][id]
+	if !ok {
+		return fmt.Errorf("tsutsutest: no job %d in queue %s", id, queueName)
+	}
+	job.Status = statusGrabbed
+	s.stats[queueName].TotalPops++
+	return nil
+}
+
+// Fail removes a job from its queue and records it as permanently failed.
+func (s *Server) Fail(queueName string, id uint64, result tsutsu.JobResult) (tsutsu.FailedJobInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[queueName][id]
+	if !ok {
+		return tsutsu.FailedJobInfo{}, fmt.Errorf("tsutsutest: no job %d in queue %s", id, queueName)
+	}
+	delete(s.jobs[queueName], id)
+
+	s.nextFailedID++
+	failed := &tsutsu.FailedJobInfo{
+		ID:        s.nextFailedID,
+		JobID:     job.ID,
+		Category:  job.Category,
+		URL:       job.URL,
+		Payload:   job.Payload,
+		Result:    &result,
+		FailCount: job.FailCount + 1,
+		FailedAt:  time.Now().UTC(),
+		CreatedAt: job.CreatedAt,
+	}
+	s.failed[queueName][failed.ID] = failed
+
+	stats := s.stats[queueName]
+	stats.TotalFailures++
+	stats.TotalPermanentFailures++
+	stats.TotalCompletes++
+	return *failed, nil
+}
+
+func (s *Server) addQueue(queue tsutsu.QueueSpec) {
+	s.queues[queue.Name] = queue
+	if _, ok := s.jobs[queue.Name]; !ok {
+		s.jobs[queue.Name] = map[uint64]*tsutsu.JobInfo{}
+		s.failed[queue.Name] = map[uint64]*tsutsu.FailedJobInfo{}
+		s.stats[queue.Name] = &tsutsu.QueueStats{}
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.latency
+	injected, hasError := s.injectedError(r)
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if hasError {
+		writeError(w, injected.status, injected.message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/" || r.URL.Path == "/version" {
+		fmt.Fprintf(w, "Fireworq %s\n", s.version)
+		return
+	}
+
+	status, v, message := s.route(r)
+	if status != http.StatusOK {
+		writeError(w, status, message)
+		return
+	}
+	writeJSON(w, v)
+}
+
+func (s *Server) injectedError(r *http.Request) (injectedError, bool) {
+	for _, e := range s.errors {
+		if e.method != "" && e.method != r.Method {
+			continue
+		}
+		if ok, _ := path.Match(e.pattern, r.URL.Path); ok {
+			return e, true
+		}
+	}
+	return injectedError{}, false
+}
+
+func (s *Server) route(r *http.Request) (int, interface{}, string) {
+	p := r.URL.Path
+	switch {
+	case p == "/queues":
+		return s.serveQueueList(r)
+	case p == "/queues/stats":
+		return s.serveQueueListStats(r)
+	case p == "/settings":
+		return http.StatusOK, s.settings(), ""
+	case p == "/routings":
+		return s.serveRoutingList(r)
+	case strings.HasPrefix(p, "/routing/") && len(p) > len("/routing/"):
+		return s.serveRouting(r, strings.TrimPrefix(p, "/routing/"))
+	case strings.HasPrefix(p, "/job/") && len(p) > len("/job/"):
+		return s.serveJob(r, strings.TrimPrefix(p, "/job/"))
+	case strings.HasPrefix(p, "/queue/"):
+		return s.serveQueuePath(r, strings.Split(strings.TrimPrefix(p, "/queue/"), "/"))
+	}
+	return http.StatusNotFound, nil, ""
+}
+
+func (s *Server) serveQueuePath(r *http.Request, segments []string) (int, interface{}, string) {
+	name := segments[0]
+	if name == "" {
+		return http.StatusNotFound, nil, ""
+	}
+	if len(segments) == 1 {
+		return s.serveQueue(r, name)
+	}
+
+	if _, ok := s.queues[name]; !ok {
+		return http.StatusNotFound, nil, "No such queue: " + name
+	}
+	if r.Method != http.MethodGet && !(r.Method == http.MethodDelete && len(segments) == 3) {
+		return http.StatusMethodNotAllowed, nil, ""
+	}
+
+	switch {
+	case len(segments) == 2 && segments[1] == "stats":
+		return http.StatusOK, s.queueStats(name), ""
+	case len(segments) == 2 && segments[1] == "node":
+		return http.StatusOK, tsutsu.NodeInfo{ID: "tsutsutest", Host: "localhost"}, ""
+	case len(segments) == 2 && segments[1] == "grabbed":
+		return s.serveJobList(r, name, func(job *tsutsu.JobInfo, now time.Time) bool {
+			return job.Status == statusGrabbed
+		})
+	case len(segments) == 2 && segments[1] == "waiting":
+		return s.serveJobList(r, name, func(job *tsutsu.JobInfo, now time.Time) bool {
+			return job.Status == statusClaimed && !job.NextTry.After(now)
+		})
+	case len(segments) == 2 && segments[1] == "deferred":
+		return s.serveJobList(r, name, func(job *tsutsu.JobInfo, now time.Time) bool {
+			return job.Status == statusClaimed && job.NextTry.After(now)
+		})
+	case len(segments) == 2 && segments[1] == "failed":
+		return s.serveFailedList(r, name)
+	case len(segments) == 3 && segments[1] == "job":
+		return s.serveQueueJob(r, name, segments[2])
+	case len(segments) == 3 && segments[1] == "failed":
+		return s.serveQueueFailedJob(r, name, segments[2])
+	}
+	return http.StatusNotFound, nil, ""
+}
+
+func (s *Server) settings() map[string]string {
+	q := s.queues[s.defaultQueue]
+	return map[string]string{
+		"driver":                         "tsutsutest",
+		"bind":                           strings.TrimPrefix(s.URL, "http://"),
+		"queue_default":                  s.defaultQueue,
+		"queue_default_polling_interval": strconv.FormatUint(uint64(q.PollingInterval), 10),
+		"queue_default_max_workers":      strconv.FormatUint(uint64(q.MaxWorkers), 10),
+		"dispatch_user_agent":            "Fireworq/" + s.version,
+	}
+}
+
+func (s *Server) serveQueueList(r *http.Request) (int, interface{}, string) {
+	queues := make([]tsutsu.QueueSpec, 0, len(s.queues))
+	for _, q := range s.queues {
+		queues = append(queues, q)
+	}
+	sort.Slice(queues, func(i, j int) bool { return queues[i].Name < queues[j].Name })
+	return http.StatusOK, queues, ""
+}
+
+func (s *Server) serveQueueListStats(r *http.Request) (int, interface{}, string) {
+	stats := make(map[string]tsutsu.QueueStats, len(s.queues))
+	for name := range s.queues {
+		stats[name] = s.queueStats(name)
+	}
+	return http.StatusOK, stats, ""
+}
+
+func (s *Server) queueStats(name string) tsutsu.QueueStats {
+	stats := *s.stats[name]
+	var grabbed int64
+	for _, job := range s.jobs[name] {
+		if job.Status == statusGrabbed {
+			grabbed++
+		}
+	}
+	stats.TotalWorkers = int64(s.queues[name].MaxWorkers)
+	stats.IdleWorkers = stats.TotalWorkers - grabbed
+	if stats.IdleWorkers < 0 {
+		stats.IdleWorkers = 0
+	}
+	stats.ActiveNodes = 1
+	return stats
+}
+
+func (s *Server) serveQueue(r *http.Request, name string) (int, interface{}, string) {
+	switch r.Method {
+	case http.MethodPut:
+		var definition tsutsu.QueueSpec
+		if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+			return http.StatusBadRequest, nil, err.Error()
+		}
+		definition.Name = name
+		if v, err := tsutsu.ParseServerVersion(s.version); err == nil && !v.AtLeast(1, 5, 0) {
+			// older servers ignore the throttling fields
+			definition.MaxDispatchesPerSecond = 0
+			definition.MaxBurstSize = 0
+		}
+		s.addQueue(definition)
+		return http.StatusOK, definition, ""
+	case http.MethodGet, http.MethodDelete:
+		q, ok := s.queues[name]
+		if !ok {
+			return http.StatusNotFound, nil, ""
+		}
+		if r.Method == http.MethodDelete {
+			delete(s.queues, name)
+			delete(s.jobs, name)
+			delete(s.failed, name)
+			delete(s.stats, name)
+		}
+		return http.StatusOK, q, ""
+	}
+	return http.StatusMethodNotAllowed, nil, ""
+}
+
+func (s *Server) serveRoutingList(r *http.Request) (int, interface{}, string) {
+	routings := make([]model.Routing, 0, len(s.routings))
+	for category, queueName := range s.routings {
+		routings = append(routings, model.Routing{QueueName: queueName, JobCategory: category})
+	}
+	sort.Slice(routings, func(i, j int) bool { return routings[i].JobCategory < routings[j].JobCategory })
+	return http.StatusOK, routings, ""
+}
+
+func (s *Server) serveRouting(r *http.Request, category string) (int, interface{}, string) {
+	switch r.Method {
+	case http.MethodPut:
+		var definition model.Routing
+		if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+			return http.StatusBadRequest, nil, err.Error()
+		}
+		definition.JobCategory = category
+		if _, ok := s.queues[definition.QueueName]; !ok {
+			return http.StatusNotFound, nil, fmt.Sprintf("Queue '%s' not found", definition.QueueName)
+		}
+		s.routings[category] = definition.QueueName
+		return http.StatusOK, definition, ""
+	case http.MethodGet, http.MethodDelete:
+		queueName, ok := s.routings[category]
+		if !ok {
+			return http.StatusNotFound, nil, ""
+		}
+		if r.Method == http.MethodDelete {
+			delete(s.routings, category)
+		}
+		return http.StatusOK, model.Routing{QueueName: queueName, JobCategory: category}, ""
+	}
+	return http.StatusMethodNotAllowed, nil, ""
+}
+
+type incomingJob struct {
+	URL        string          `json:"url"`
+	Payload    json.RawMessage `json:"payload"`
+	RunAfter   uint            `json:"run_after"`
+	Timeout    uint            `json:"timeout"`
+	RetryDelay uint            `json:"retry_delay"`
+	MaxRetries uint            `json:"max_retries"`
+}
+
+func (s *Server) serveJob(r *http.Request, category string) (int, interface{}, string) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, nil, ""
+	}
+
+	var job incomingJob
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		return http.StatusBadRequest, nil, err.Error()
+	}
+	if job.URL == "" {
+		return http.StatusBadRequest, nil, "Missing field: url"
+	}
+
+	queueName, ok := s.routings[category]
+	if !ok {
+		queueName = s.defaultQueue
+	}
+	if _, ok := s.queues[queueName]; !ok {
+		return http.StatusInternalServerError, nil, fmt.Sprintf("No routing of job category '%s' exists", category)
+	}
+
+	s.nextJobID++
+	now := time.Now().UTC()
+	s.jobs[queueName][s.nextJobID] = &tsutsu.JobInfo{
+		ID:         s.nextJobID,
+		Category:   category,
+		URL:        job.URL,
+		Payload:    job.Payload,
+		Status:     statusClaimed,
+		CreatedAt:  now,
+		NextTry:    now.Add(time.Duration(job.RunAfter) * time.Second),
+		Timeout:    job.Timeout,
+		MaxRetries: job.MaxRetries,
+		RetryDelay: job.RetryDelay,
+	}
+	s.stats[queueName].TotalPushes++
+
+	return http.StatusOK, tsutsu.PushResult{
+		ID:         s.nextJobID,
+		QueueName:  queueName,
+		Category:   category,
+		URL:        job.URL,
+		Payload:    job.Payload,
+		RunAfter:   job.RunAfter,
+		Timeout:    job.Timeout,
+		MaxRetries: job.MaxRetries,
+		RetryDelay: job.RetryDelay,
+	}, ""
+}
+
+func (s *Server) serveJobList(r *http.Request, queueName string, match func(*tsutsu.JobInfo, time.Time) bool) (int, interface{}, string) {
+	now := time.Now()
+	var ids []uint64
+	for id, job := range s.jobs[queueName] {
+		if match(job, now) {
+			ids = append(ids, id)
+		}
+	}
+
+	page, next := paginate(r, ids)
+	jobs := make([]tsutsu.JobInfo, 0, len(page))
+	for _, id := range page {
+		jobs = append(jobs, *s.jobs[queueName][id])
+	}
+	return http.StatusOK, tsutsu.JobsInfo{Jobs: jobs, NextCursor: next}, ""
+}
+
+func (s *Server) serveFailedList(r *http.Request, queueName string) (int, interface{}, string) {
+	ids := make([]uint64, 0, len(s.failed[queueName]))
+	for id := range s.failed[queueName] {
+		ids = append(ids, id)
+	}
+
+	page, next := paginate(r, ids)
+	jobs := make([]tsutsu.FailedJobInfo, 0, len(page))
+	for _, id := range page {
+		jobs = append(jobs, *s.failed[queueName][id])
+	}
+	return http.StatusOK, tsutsu.FailedJobsInfo{FailedJobs: jobs, NextCursor: next}, ""
+}
+
+// paginate sorts ids by the order parameter and returns the page starting at
+// the cursor, which is the id of its first element.
+func paginate(r *http.Request, ids []uint64) ([]uint64, string) {
+	query := r.URL.Query()
+	asc := query.Get("order") == "asc"
+	sort.Slice(ids, func(i, j int) bool {
+		if asc {
+			return ids[i] < ids[j]
+		}
+		return ids[i] > ids[j]
+	})
+
+	if cursor, err := strconv.ParseUint(query.Get("cursor"), 10, 64); err == nil {
+		start := sort.Search(len(ids), func(i int) bool {
+			if asc {
+				return ids[i] >= cursor
+			}
+			return ids[i] <= cursor
+		})
+		ids = ids[start:]
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if len(ids) <= limit {
+		return ids, ""
+	}
+	return ids[:limit], strconv.FormatUint(ids[limit], 10)
+}
+
+func (s *Server) serveQueueJob(r *http.Request, queueName, rawID string) (int, interface{}, string) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return http.StatusBadRequest, nil, ""
+	}
+	job, ok := s.jobs[queueName][id]
+	if !ok {
+		return http.StatusNotFound, nil, ""
+	}
+	if r.Method == http.MethodDelete {
+		delete(s.jobs[queueName], id)
+	}
+	return http.StatusOK, *job, ""
+}
+
+func (s *Server) serveQueueFailedJob(r *http.Request, queueName, rawID string) (int, interface{}, string) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return http.StatusBadRequest, nil, ""
+	}
+	job, ok := s.failed[queueName][id]
+	if !ok {
+		return http.StatusNotFound, nil, ""
+	}
+	if r.Method == http.MethodDelete {
+		delete(s.failed[queueName], id)
+	}
+	return http.StatusOK, *job, ""
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
+
+// writeError mimics the plain text error body of Fireworq.
+func writeError(w http.ResponseWriter, status int, message string) {
+	body := fmt.Sprintf("%d %s", status, http.StatusText(status))
+	if message != "" {
+		body += "\n\n" + message
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, body)
+}
@@ -0,0 +1,180 @@
+package tsutsutest
+
+import (
+	"context"
+	"errors"
+	"github.com/fireworq/fireworq/model"
+	"github.com/stk132/tsutsu"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServer_queuesAndRoutings(t1 *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	if _, err := t.CreateQueue("test_queue", 100, 2); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := t.CreateRouting("test_category", "test_queue"); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := t.CreateRouting("orphan", "not_found"); !errors.Is(err, tsutsu.ErrNotFound) {
+		t1.Errorf("CreateRouting() error = %v, want ErrNotFound", err)
+	}
+
+	queues, err := t.Queues()
+	if err != nil {
+		t1.Fatal(err)
+	}
+	wantQueues := []model.Queue{
+		{Name: "default", PollingInterval: 200, MaxWorkers: 20},
+		{Name: "test_queue", PollingInterval: 100, MaxWorkers: 2},
+	}
+	if !reflect.DeepEqual(queues, wantQueues) {
+		t1.Errorf("Queues() got = %v, want %v", queues, wantQueues)
+	}
+
+	routing, err := t.DeleteRouting("test_category")
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if want := (model.Routing{QueueName: "test_queue", JobCategory: "test_category"}); routing != want {
+		t1.Errorf("DeleteRouting() got = %v, want %v", routing, want)
+	}
+	if _, err := t.Routing("test_category"); !errors.Is(err, tsutsu.ErrNotFound) {
+		t1.Errorf("Routing() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestServer_jobs(t1 *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		res, err := t.PushJob("test_category", tsutsu.JobRequest{URL: "http://localhost/"})
+		if err != nil {
+			t1.Fatal(err)
+		}
+		ids = append(ids, res.ID)
+	}
+	if _, err := t.PushJob("test_category", tsutsu.JobRequest{URL: "http://localhost/", RunAfter: 3600}); err != nil {
+		t1.Fatal(err)
+	}
+	if err := s.Grab("default", ids[0]); err != nil {
+		t1.Fatal(err)
+	}
+	if _, err := s.Fail("default", ids[1], tsutsu.JobResult{Status: "permanent-failure", Code: 500}); err != nil {
+		t1.Fatal(err)
+	}
+
+	var waiting []uint64
+	err := t.Job().Limit(2).Asc().WaitingAll("default", func(job tsutsu.JobInfo) error {
+		waiting = append(waiting, job.ID)
+		return nil
+	})
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if want := ids[2:]; !reflect.DeepEqual(waiting, want) {
+		t1.Errorf("WaitingAll() got = %v, want %v", waiting, want)
+	}
+
+	lists := []struct {
+		name string
+		list func(string) (tsutsu.JobsInfo, error)
+		want int
+	}{
+		{name: "grabbed", list: t.Job().Grabbed, want: 1},
+		{name: "deferred", list: t.Job().Deferred, want: 1},
+	}
+	for _, l := range lists {
+		info, err := l.list("default")
+		if err != nil {
+			t1.Fatal(err)
+		}
+		if len(info.Jobs) != l.want {
+			t1.Errorf("%s got %d jobs, want %d", l.name, len(info.Jobs), l.want)
+		}
+	}
+
+	failed, err := t.Job().Failed("default")
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if len(failed.FailedJobs) != 1 || failed.FailedJobs[0].JobID != ids[1] {
+		t1.Errorf("Failed() got = %v, want job %d", failed.FailedJobs, ids[1])
+	}
+
+	stats, err := t.Stats("default")
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if stats.TotalPushes != 6 || stats.TotalPermanentFailures != 1 || stats.IdleWorkers != 19 {
+		t1.Errorf("Stats() got = %+v", stats)
+	}
+}
+
+func TestServer_InjectError(t1 *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	s.InjectError(http.MethodGet, "/queue/*/stats", http.StatusServiceUnavailable, "maintenance")
+	var apiErr *tsutsu.APIError
+	if _, err := t.Stats("default"); !errors.As(err, &apiErr) || apiErr.Message != "maintenance" {
+		t1.Errorf("Stats() error = %v, want injected error", err)
+	}
+	if _, err := t.Node("default"); err != nil {
+		t1.Errorf("Node() error = %v", err)
+	}
+
+	s.ClearErrors()
+	if _, err := t.Stats("default"); err != nil {
+		t1.Errorf("Stats() error = %v", err)
+	}
+}
+
+func TestServer_SetLatency(t1 *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	t := s.Tsutsu()
+
+	s.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := t.QueuesWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t1.Errorf("QueuesWithContext() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestServer_PutQueue(t1 *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.SetVersion("1.5.0")
+
+	spec := tsutsu.QueueSpec{
+		Name:                   "throttled",
+		PollingInterval:        100,
+		MaxWorkers:             2,
+		MaxDispatchesPerSecond: 1.5,
+		MaxBurstSize:           3,
+	}
+	got, err := s.Tsutsu().PutQueue(spec)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if got != spec {
+		t1.Errorf("PutQueue() got = %v, want %v", got, spec)
+	}
+
+	s.SetVersion("1.4.0")
+	if _, err := s.Tsutsu().PutQueue(spec); !errors.Is(err, tsutsu.ErrUnsupportedByServer) {
+		t1.Errorf("PutQueue() error = %v, want ErrUnsupportedByServer", err)
+	}
+}
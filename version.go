@@ -0,0 +1,175 @@
+package tsutsu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedByServer = errors.New("tsutsu: unsupported by server")
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?`)
+
+// ServerVersion is the version reported by GET /version, whose body looks
+// like "Fireworq 1.4.0".
+type ServerVersion struct {
+	Raw        string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+func ParseServerVersion(raw string) (ServerVersion, error) {
+	raw = strings.TrimSpace(raw)
+	m := versionPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return ServerVersion{}, fmt.Errorf("tsutsu: cannot parse server version %q", raw)
+	}
+
+	v := ServerVersion{Raw: raw, Prerelease: m[4]}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, nil
+}
+
+func (v ServerVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// AtLeast ignores the pre-release part, so development builds count as the
+// release they lead up to.
+func (v ServerVersion) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+type Settings struct {
+	Driver                      string
+	Bind                        string
+	QueueDefault                string
+	QueueDefaultPollingInterval uint
+	QueueDefaultMaxWorkers      uint
+	ConfigRefreshInterval       uint
+	ShutdownTimeout             uint
+	KeepAlive                   bool
+	DispatchUserAgent           string
+	Raw                         map[string]string
+}
+
+func newSettings(raw map[string]string) Settings {
+	return Settings{
+		Driver:                      raw["driver"],
+		Bind:                        raw["bind"],
+		QueueDefault:                raw["queue_default"],
+		QueueDefaultPollingInterval: parseUint(raw["queue_default_polling_interval"]),
+		QueueDefaultMaxWorkers:      parseUint(raw["queue_default_max_workers"]),
+		ConfigRefreshInterval:       parseUint(raw["config_refresh_interval"]),
+		ShutdownTimeout:             parseUint(raw["shutdown_timeout"]),
+		KeepAlive:                   raw["keep_alive"] == "true",
+		DispatchUserAgent:           raw["dispatch_user_agent"],
+		Raw:                         raw,
+	}
+}
+
+func parseUint(s string) uint {
+	n, _ := strconv.ParseUint(s, 10, 0)
+	return uint(n)
+}
+
+func (t *Tsutsu) Version() (ServerVersion, error) {
+	return t.VersionWithContext(context.Background())
+}
+
+func (t *Tsutsu) VersionWithContext(ctx context.Context) (ServerVersion, error) {
+	ctx = withOperation(ctx, "Version")
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("version").String())
+	if err != nil {
+		return ServerVersion{}, err
+	}
+
+	defer decoder.Close()
+
+	raw, err := decoder.ReadString()
+	if err != nil {
+		return ServerVersion{}, err
+	}
+
+	return ParseServerVersion(raw)
+}
+
+func (t *Tsutsu) Settings() (Settings, error) {
+	return t.SettingsWithContext(context.Background())
+}
+
+func (t *Tsutsu) SettingsWithContext(ctx context.Context) (Settings, error) {
+	ctx = withOperation(ctx, "Settings")
+
+	decoder, err := t.getWithContext(ctx, t.endpoint("settings").String())
+	if err != nil {
+		return Settings{}, err
+	}
+
+	defer decoder.Close()
+
+	var raw map[string]string
+	if err := decoder.Decode(&raw); err != nil {
+		return Settings{}, err
+	}
+
+	return newSettings(raw), nil
+}
+
+// serverVersion caches the first successful Version call.
+func (t *Tsutsu) serverVersion(ctx context.Context) (ServerVersion, error) {
+	t.versionMu.Lock()
+	defer t.versionMu.Unlock()
+
+	if t.version != nil {
+		return *t.version, nil
+	}
+
+	v, err := t.VersionWithContext(ctx)
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	t.version = &v
+	return v, nil
+}
+
+type feature struct {
+	name                string
+	major, minor, patch int
+}
+
+// Fireworq 1.4.0, which this module is built against, has no throttling
+// settings on queues and drops them silently, so they are sent only to
+// releases after it.
+var featureQueueThrottling = feature{name: "queue dispatch throttling", major: 1, minor: 5, patch: 0}
+
+// requireFeature returns ErrUnsupportedByServer when the server is older than
+// the release that introduced f.
+func (t *Tsutsu) requireFeature(ctx context.Context, f feature) error {
+	v, err := t.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if !v.AtLeast(f.major, f.minor, f.patch) {
+		return fmt.Errorf("%w: %s requires Fireworq %d.%d.%d, server is %s", ErrUnsupportedByServer, f.name, f.major, f.minor, f.patch, v)
+	}
+	return nil
+}
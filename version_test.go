@@ -0,0 +1,83 @@
+package tsutsu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseServerVersion(t1 *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    ServerVersion
+		wantErr bool
+	}{
+		{
+			name: "release",
+			raw:  "Fireworq 1.4.0\n",
+			want: ServerVersion{Raw: "Fireworq 1.4.0", Major: 1, Minor: 4, Patch: 0},
+		},
+		{
+			name: "development build",
+			raw:  "Fireworq 1.5.0-DEV (rev abc)",
+			want: ServerVersion{Raw: "Fireworq 1.5.0-DEV (rev abc)", Major: 1, Minor: 5, Patch: 0, Prerelease: "DEV"},
+		},
+		{
+			name:    "broken",
+			raw:     "Fireworq",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			got, err := ParseServerVersion(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t1.Errorf("ParseServerVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t1.Errorf("ParseServerVersion() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerVersion_AtLeast(t1 *testing.T) {
+	v := ServerVersion{Major: 1, Minor: 4, Patch: 2}
+	tests := []struct {
+		major, minor, patch int
+		want                bool
+	}{
+		{1, 4, 2, true},
+		{1, 4, 3, false},
+		{1, 3, 9, true},
+		{1, 5, 0, false},
+		{0, 9, 9, true},
+		{2, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := v.AtLeast(tt.major, tt.minor, tt.patch); got != tt.want {
+			t1.Errorf("AtLeast(%d, %d, %d) = %v, want %v", tt.major, tt.minor, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestTsutsu_VersionAndSettings(t1 *testing.T) {
+	t := NewTsutsu(FIREWORQ_URL)
+
+	v, err := t.Version()
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if !v.AtLeast(1, 0, 0) {
+		t1.Errorf("Version() got = %v, want at least 1.0.0", v)
+	}
+
+	settings, err := t.Settings()
+	if err != nil {
+		t1.Fatal(err)
+	}
+	if settings.QueueDefault != "default" || settings.Raw["queue_default"] != "default" {
+		t1.Errorf("Settings() got = %+v, want default queue", settings)
+	}
+}